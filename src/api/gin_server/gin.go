@@ -36,9 +36,9 @@ func New(logger logger.Logger, factory *factory.Factory, validator validator.Val
 func (s *Gin) SetupCors() {
 	s.Gin.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"}, // Ajuste a origem do seu frontend aqui
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
 		AllowHeaders:     []string{"Origin", "Authorization", "Content-Type"},
-		ExposeHeaders:    []string{"Content-Length"},
+		ExposeHeaders:    []string{"Content-Length", "Location"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -79,7 +79,7 @@ func (s *Gin) SetupApi(ctx context.Context, staticFilesPath string) error {
 
 	//Handlers
 	authHandler := handlers.NewAuthHandler(s.factory.UserManager.UseCases, s.validator)
-	monitorHandlers := handlers.NewCameraHandler(s.factory.Monitoring.UseCases)
+	monitorHandlers := handlers.NewCameraHandler(s.factory.Monitoring.UseCases, s.validator)
 
 	//Routes
 	routes.ConfigAuthRoutes(apiRoutes, authHandler, authMiddleware)
@@ -1,19 +1,155 @@
 package handlers
 
 import (
+	"io"
+	"mime"
+	"mime/multipart"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
 	monitoring_use_cases "monitoring-system/src/internal/modules/monitoring/usecases"
+	"monitoring-system/src/pkg/app_error"
+	"monitoring-system/src/pkg/validator"
 	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	MJPEG_BOUNDARY    = "frame"
+	MJPEG_DEFAULT_FPS = 10
+	// WHEP_MAX_OFFER_SIZE bounds the SDP offers read from clients
+	WHEP_MAX_OFFER_SIZE = 64 << 10
+)
+
 type CameraHandler struct {
-	uc *monitoring_use_cases.MonitoringUseCases
+	uc        *monitoring_use_cases.MonitoringUseCases
+	validator validator.Validator
+}
+
+type SnapshotRequest struct {
+	Width   int `form:"width" validate:"omitempty,min=1,max=4096"`
+	Height  int `form:"height" validate:"omitempty,min=1,max=4096"`
+	Quality int `form:"quality" validate:"omitempty,min=1,max=100"`
+}
+
+type MJPEGRequest struct {
+	FPS int `form:"fps" validate:"omitempty,min=1,max=30"`
+}
+
+type CameraSettingsRequest struct {
+	Width         int                    `json:"width" validate:"omitempty,min=1"`
+	Height        int                    `json:"height" validate:"omitempty,min=1"`
+	FPS           int                    `json:"fps" validate:"omitempty,min=1,max=120"`
+	Codec         string                 `json:"codec" validate:"omitempty,len=4"`
+	JPEGQuality   int                    `json:"jpeg_quality" validate:"omitempty,min=1,max=100"`
+	MinArea       int                    `json:"min_area" validate:"omitempty,min=1"`
+	RecordingMode string                 `json:"recording_mode" validate:"omitempty,oneof=always motion off"`
+	Overlay       *bool                  `json:"overlay"`
+	Loop          *bool                  `json:"loop"`
+	Motion        *MotionSettingsRequest `json:"motion"`
+}
+
+type MotionSettingsRequest struct {
+	Algorithm     string  `json:"algorithm" validate:"omitempty,oneof=mog2 knn diff"`
+	History       int     `json:"history" validate:"omitempty,min=1"`
+	VarThreshold  float64 `json:"var_threshold" validate:"omitempty,gt=0"`
+	DetectShadows *bool   `json:"detect_shadows"`
+	Threshold     int     `json:"threshold" validate:"omitempty,min=1,max=254"`
+	KernelSize    int     `json:"kernel_size" validate:"omitempty,min=1,max=31"`
+	MinFrames     int     `json:"min_frames" validate:"omitempty,min=1"`
+}
+
+func (r CameraSettingsRequest) settings() camera.Settings {
+	return camera.Settings{
+		Width:         r.Width,
+		Height:        r.Height,
+		FPS:           r.FPS,
+		Codec:         r.Codec,
+		JPEGQuality:   r.JPEGQuality,
+		MinArea:       r.MinArea,
+		RecordingMode: r.RecordingMode,
+		Overlay:       r.Overlay,
+		Loop:          r.Loop,
+		Motion:        r.Motion.settings(),
+	}
+}
+
+func (r *MotionSettingsRequest) settings() *motion.Settings {
+	if r == nil {
+		return nil
+	}
+	return &motion.Settings{
+		Algorithm:     motion.Algorithm(r.Algorithm),
+		History:       r.History,
+		VarThreshold:  r.VarThreshold,
+		DetectShadows: r.DetectShadows,
+		Threshold:     r.Threshold,
+		KernelSize:    r.KernelSize,
+		MinFrames:     r.MinFrames,
+	}
+}
+
+type AddCameraRequest struct {
+	Name     string                `json:"name" validate:"max=100"`
+	Type     string                `json:"type" binding:"required" validate:"oneof=device stream images virtual file"`
+	Source   string                `json:"source" binding:"required"`
+	Settings CameraSettingsRequest `json:"settings"`
+}
+
+type UpdateCameraRequest struct {
+	Name     *string                `json:"name" validate:"omitempty,min=1,max=100"`
+	Source   *string                `json:"source" validate:"omitempty,min=1"`
+	Settings *CameraSettingsRequest `json:"settings"`
+	Enabled  *bool                  `json:"enabled"`
 }
 
-func NewCameraHandler(uc *monitoring_use_cases.MonitoringUseCases) *CameraHandler {
+type RenameCameraRequest struct {
+	Name string `json:"name" binding:"required" validate:"min=1,max=100"`
+}
+
+type ListRecordingsRequest struct {
+	CameraID string    `form:"camera_id"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Motion   *bool     `form:"motion"`
+	Page     int       `form:"page" validate:"omitempty,min=1"`
+	PageSize int       `form:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+type ListMotionEventsRequest struct {
+	CameraID string    `form:"camera_id"`
+	From     time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page     int       `form:"page" validate:"omitempty,min=1"`
+	PageSize int       `form:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+type MotionZoneRequest struct {
+	Name    string         `json:"name" validate:"max=100"`
+	Kind    string         `json:"kind" binding:"required" validate:"oneof=include exclude"`
+	Points  []motion.Point `json:"points" binding:"required" validate:"min=3,max=100"`
+	Enabled *bool          `json:"enabled"`
+}
+
+type UpdateMotionZoneRequest struct {
+	Name    *string         `json:"name" validate:"omitempty,max=100"`
+	Kind    *string         `json:"kind" validate:"omitempty,oneof=include exclude"`
+	Points  *[]motion.Point `json:"points" validate:"omitempty,min=3,max=100"`
+	Enabled *bool           `json:"enabled"`
+}
+
+func NewCameraHandler(uc *monitoring_use_cases.MonitoringUseCases, validator validator.Validator) *CameraHandler {
 	return &CameraHandler{
-		uc: uc,
+		uc:        uc,
+		validator: validator,
 	}
 }
 
@@ -28,3 +164,500 @@ func (a *CameraHandler) GetCameraDetails() gin.HandlerFunc {
 		}
 	}
 }
+
+func (a *CameraHandler) GetSnapshot() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req SnapshotRequest
+		if err := g.ShouldBindQuery(&req); err != nil {
+			g.Error(err)
+			return
+		}
+
+		err := a.validator.Validate(&req)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+
+		res, err := a.uc.CameraInfoUseCase.GetSnapshot(g.Request.Context(), g.Param("id"), camera.SnapshotOptions{
+			Width:   req.Width,
+			Height:  req.Height,
+			Quality: req.Quality,
+		})
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.Header("Cache-Control", "no-store")
+			g.Data(http.StatusOK, "image/jpeg", res)
+		}
+	}
+}
+
+// StreamMJPEG serves the camera as multipart/x-mixed-replace, readable by
+// <img> tags, VLC and most NVRs.
+func (a *CameraHandler) StreamMJPEG() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req MJPEGRequest
+		if err := g.ShouldBindQuery(&req); err != nil {
+			g.Error(err)
+			return
+		}
+
+		err := a.validator.Validate(&req)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+		if req.FPS == 0 {
+			req.FPS = MJPEG_DEFAULT_FPS
+		}
+
+		sub, err := a.uc.CameraInfoUseCase.Subscribe(g.Param("id"))
+		if err != nil {
+			g.Error(err)
+			return
+		}
+		defer sub.Close()
+
+		writer := multipart.NewWriter(g.Writer)
+		writer.SetBoundary(MJPEG_BOUNDARY)
+
+		g.Header("Content-Type", "multipart/x-mixed-replace; boundary="+MJPEG_BOUNDARY)
+		g.Header("Cache-Control", "no-cache, no-store")
+		g.Status(http.StatusOK)
+
+		ticker := time.NewTicker(time.Second / time.Duration(req.FPS))
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-g.Request.Context().Done():
+				return
+			case <-ticker.C:
+				img, err := sub.Capture()
+				if err != nil {
+					return
+				}
+
+				part, err := writer.CreatePart(textproto.MIMEHeader{
+					"Content-Type":   {"image/jpeg"},
+					"Content-Length": {strconv.Itoa(len(img))},
+				})
+				if err != nil {
+					return
+				}
+				if _, err := part.Write(img); err != nil {
+					return
+				}
+				g.Writer.Flush()
+			}
+		}
+	}
+}
+
+// ServeHLS serves the playlist and segments of a camera. The token of the
+// playlist request is forwarded to the segments, players do not send headers.
+func (a *CameraHandler) ServeHLS() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		id, file := g.Param("id"), g.Param("file")
+
+		if file != monitoring_use_cases.HLS_PLAYLIST {
+			path, err := a.uc.HLSUseCase.Segment(id, file)
+			if err != nil {
+				g.Error(err)
+				return
+			}
+			g.Header("Content-Type", "video/mp2t")
+			g.File(path)
+			return
+		}
+
+		playlist, err := a.uc.HLSUseCase.Playlist(g.Request.Context(), id)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+
+		if token := g.Query("token"); token != "" {
+			query := "?token=" + url.QueryEscape(token)
+			lines := strings.Split(string(playlist), "\n")
+			for i, line := range lines {
+				if line != "" && !strings.HasPrefix(line, "#") {
+					lines[i] = line + query
+				}
+			}
+			playlist = []byte(strings.Join(lines, "\n"))
+		}
+
+		g.Header("Cache-Control", "no-cache")
+		g.Data(http.StatusOK, "application/vnd.apple.mpegurl", playlist)
+	}
+}
+
+// WHEPOffer answers the SDP offer of a WHEP client, the Location header
+// points to the session resource used to hang up.
+func (a *CameraHandler) WHEPOffer() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		if contentType := g.ContentType(); contentType != "application/sdp" {
+			g.Error(app_error.NewApiError(http.StatusUnsupportedMediaType, "Unsupported content type", "expected application/sdp"))
+			return
+		}
+
+		offer, err := io.ReadAll(io.LimitReader(g.Request.Body, WHEP_MAX_OFFER_SIZE))
+		if err != nil {
+			g.Error(app_error.NewApiError(http.StatusBadRequest, "Invalid offer", err.Error()))
+			return
+		}
+
+		session, err := a.uc.WebRTCUseCase.Offer(g.Request.Context(), g.Param("id"), string(offer))
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.Header("Location", path.Join(g.Request.URL.Path, session.ID))
+			g.Data(http.StatusCreated, "application/sdp", []byte(session.Answer))
+		}
+	}
+}
+
+func (a *CameraHandler) WHEPHangup() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		err := a.uc.WebRTCUseCase.Hangup(g.Param("id"), g.Param("session"))
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.Status(http.StatusOK)
+		}
+	}
+}
+
+func (a *CameraHandler) ListCameras() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		res, err := a.uc.CameraManagementUseCase.ListCameras(g.Request.Context())
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, res)
+		}
+	}
+}
+
+func (a *CameraHandler) UpdateCamera() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req UpdateCameraRequest
+		if err := g.ShouldBindJSON(&req); err != nil {
+			g.Error(err)
+			return
+		}
+
+		err := a.validator.Validate(&req)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+
+		update := camera.Update{
+			Name:    req.Name,
+			Source:  req.Source,
+			Enabled: req.Enabled,
+		}
+		if req.Settings != nil {
+			settings := req.Settings.settings()
+			update.Settings = &settings
+		}
+
+		res, err := a.uc.CameraManagementUseCase.UpdateCamera(g.Request.Context(), g.Param("id"), update)
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, res)
+		}
+	}
+}
+
+func (a *CameraHandler) AddCamera() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req AddCameraRequest
+		if err := g.ShouldBindJSON(&req); err != nil {
+			g.Error(err)
+			return
+		}
+
+		err := a.validator.Validate(&req)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+
+		res, err := a.uc.CameraManagementUseCase.AddCamera(g.Request.Context(), camera.Definition{
+			Name:     req.Name,
+			Type:     camera.SourceType(req.Type),
+			Source:   req.Source,
+			Settings: req.Settings.settings(),
+		})
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusCreated, res)
+		}
+	}
+}
+
+func (a *CameraHandler) RemoveCamera() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		err := a.uc.CameraManagementUseCase.RemoveCamera(g.Request.Context(), g.Param("id"))
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, gin.H{"message": "Camera removed successfully"})
+		}
+	}
+}
+
+func (a *CameraHandler) RestartCamera() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		res, err := a.uc.CameraManagementUseCase.RestartCamera(g.Request.Context(), g.Param("id"))
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, res)
+		}
+	}
+}
+
+func (a *CameraHandler) RenameCamera() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req RenameCameraRequest
+		if err := g.ShouldBindJSON(&req); err != nil {
+			g.Error(err)
+			return
+		}
+
+		err := a.validator.Validate(&req)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+
+		res, err := a.uc.CameraManagementUseCase.RenameCamera(g.Request.Context(), g.Param("id"), req.Name)
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, res)
+		}
+	}
+}
+
+func (a *CameraHandler) GetStorageUsage() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		res, err := a.uc.StorageInfoUseCase.GetStorageUsage()
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, res)
+		}
+	}
+}
+
+func (a *CameraHandler) ListRecordings() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req ListRecordingsRequest
+		if err := g.ShouldBindQuery(&req); err != nil {
+			g.Error(err)
+			return
+		}
+
+		err := a.validator.Validate(&req)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+
+		// Under /cameras/:id the camera comes from the path
+		if id := g.Param("id"); id != "" {
+			req.CameraID = id
+		}
+
+		res, err := a.uc.RecordingsUseCase.ListRecordings(g.Request.Context(), recording.Filter{
+			CameraID: req.CameraID,
+			From:     req.From,
+			To:       req.To,
+			Motion:   req.Motion,
+			Page:     req.Page,
+			PageSize: req.PageSize,
+		})
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, res)
+		}
+	}
+}
+
+func (a *CameraHandler) PlayRecording() gin.HandlerFunc {
+	return a.serveRecording(false)
+}
+
+func (a *CameraHandler) DownloadRecording() gin.HandlerFunc {
+	return a.serveRecording(true)
+}
+
+func (a *CameraHandler) serveRecording(download bool) gin.HandlerFunc {
+	return func(g *gin.Context) {
+		segment, file, err := a.uc.RecordingsUseCase.OpenRecording(g.Request.Context(), g.Param("id"))
+		if err != nil {
+			g.Error(err)
+			return
+		}
+		defer file.Close()
+
+		info, err := file.Stat()
+		if err != nil {
+			g.Error(err)
+			return
+		}
+
+		name := filepath.Base(segment.Path)
+		if contentType := mime.TypeByExtension(filepath.Ext(name)); contentType != "" {
+			g.Header("Content-Type", contentType)
+		} else if filepath.Ext(name) == ".avi" {
+			g.Header("Content-Type", "video/x-msvideo")
+		}
+
+		if download {
+			g.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+		}
+
+		// ServeContent takes care of Range and conditional requests so the player can seek
+		http.ServeContent(g.Writer, g.Request, name, info.ModTime(), file)
+	}
+}
+
+func (a *CameraHandler) ListMotionEvents() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req ListMotionEventsRequest
+		if err := g.ShouldBindQuery(&req); err != nil {
+			g.Error(err)
+			return
+		}
+
+		err := a.validator.Validate(&req)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+
+		res, err := a.uc.MotionEventsUseCase.ListEvents(g.Request.Context(), motion.Filter{
+			CameraID: req.CameraID,
+			From:     req.From,
+			To:       req.To,
+			Page:     req.Page,
+			PageSize: req.PageSize,
+		})
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, res)
+		}
+	}
+}
+
+func (a *CameraHandler) ListMotionZones() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		res, err := a.uc.MotionZonesUseCase.ListZones(g.Request.Context(), g.Param("id"))
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, res)
+		}
+	}
+}
+
+func (a *CameraHandler) AddMotionZone() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req MotionZoneRequest
+		if err := g.ShouldBindJSON(&req); err != nil {
+			g.Error(err)
+			return
+		}
+
+		err := a.validator.Validate(&req)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+
+		zone := motion.Zone{
+			CameraID: g.Param("id"),
+			Name:     req.Name,
+			Kind:     motion.ZoneKind(req.Kind),
+			Points:   req.Points,
+			Enabled:  req.Enabled == nil || *req.Enabled,
+		}
+		res, err := a.uc.MotionZonesUseCase.AddZone(g.Request.Context(), zone)
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusCreated, res)
+		}
+	}
+}
+
+func (a *CameraHandler) UpdateMotionZone() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		var req UpdateMotionZoneRequest
+		if err := g.ShouldBindJSON(&req); err != nil {
+			g.Error(err)
+			return
+		}
+
+		err := a.validator.Validate(&req)
+		if err != nil {
+			g.Error(err)
+			return
+		}
+
+		update := motion.ZoneUpdate{
+			Name:    req.Name,
+			Points:  req.Points,
+			Enabled: req.Enabled,
+		}
+		if req.Kind != nil {
+			kind := motion.ZoneKind(*req.Kind)
+			update.Kind = &kind
+		}
+
+		res, err := a.uc.MotionZonesUseCase.UpdateZone(g.Request.Context(), g.Param("id"), g.Param("zone"), update)
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, res)
+		}
+	}
+}
+
+func (a *CameraHandler) RemoveMotionZone() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		err := a.uc.MotionZonesUseCase.RemoveZone(g.Request.Context(), g.Param("id"), g.Param("zone"))
+		if err != nil {
+			g.Error(err)
+			return
+		} else {
+			g.JSON(http.StatusOK, gin.H{"message": "Motion zone removed successfully"})
+		}
+	}
+}
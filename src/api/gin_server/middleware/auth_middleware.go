@@ -3,6 +3,7 @@ package middleware
 import (
 	"monitoring-system/src/internal/modules/user-manager/domain/auth"
 	"monitoring-system/src/pkg/logger"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,6 +12,7 @@ type AuthMiddleware interface {
 	AuthMiddleware() gin.HandlerFunc
 	AuthMiddlewareRegister() gin.HandlerFunc
 	AuthMiddlewareWs() gin.HandlerFunc
+	AuthMiddlewareMedia() gin.HandlerFunc
 }
 
 type AuthMiddlewareImpl struct {
@@ -27,32 +29,39 @@ func NewAuthMiddleware(a auth.AuthService, repo auth.AuthRepository, logger logg
 	}
 }
 
-func (a *AuthMiddlewareImpl) AuthMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
-			return
-		}
+// bearerToken reads the token of the Authorization header.
+func bearerToken(c *gin.Context) string {
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
 
-		token := authHeader[7:]
+func (a *AuthMiddlewareImpl) authenticate(c *gin.Context, token string) {
+	if token == "" {
+		c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
 
-		claims, err := a.auth.ValidateToken(token)
-		if err != nil {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
-			return
-		}
+	claims, err := a.auth.ValidateToken(token)
+	if err != nil {
+		c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
 
-		user, err := a.authRepo.GetByUsername(c.Request.Context(), claims.Username)
-		if err != nil {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
-		}
+	user, err := a.authRepo.GetByUsername(c.Request.Context(), claims.Username)
+	if err != nil {
+		c.AbortWithStatusJSON(401, gin.H{"error": "Unauthorized"})
+		return
+	}
 
-		c.Set("jwtToken", token)
-		c.Set("claims", claims)
-		c.Set("user", user)
+	c.Set("jwtToken", token)
+	c.Set("claims", claims)
+	c.Set("user", user)
 
-		c.Next()
+	c.Next()
+}
+
+func (a *AuthMiddlewareImpl) AuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		a.authenticate(c, bearerToken(c))
 	}
 }
 
@@ -92,6 +101,18 @@ func (a *AuthMiddlewareImpl) AuthMiddlewareRegister() gin.HandlerFunc {
 	}
 }
 
+// AuthMiddlewareMedia also accepts the token query parameter, only for the
+// media routes played by <video> and <img>, which can't set headers.
+func (a *AuthMiddlewareImpl) AuthMiddlewareMedia() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c)
+		if token == "" {
+			token = c.Query("token")
+		}
+		a.authenticate(c, token)
+	}
+}
+
 func (a *AuthMiddlewareImpl) AuthMiddlewareWs() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		token := c.Query("token")
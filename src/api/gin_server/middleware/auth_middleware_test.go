@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"monitoring-system/src/internal/modules/user-manager/domain/auth"
+	"monitoring-system/src/pkg/logger"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+const validToken = "valid"
+
+type fakeAuth struct {
+	auth.AuthService
+}
+
+func (fakeAuth) ValidateToken(token string) (*auth.Claims, error) {
+	if token != validToken {
+		return nil, errors.New("invalid token")
+	}
+	return &auth.Claims{Username: "admin"}, nil
+}
+
+type fakeAuthRepository struct {
+	auth.AuthRepository
+}
+
+func (fakeAuthRepository) GetByUsername(ctx context.Context, username string) (*auth.AuthEntity, error) {
+	return &auth.AuthEntity{Username: username}, nil
+}
+
+func TestQueryTokenOnlyOnMediaRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log, err := logger.NewLogger("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewAuthMiddleware(fakeAuth{}, fakeAuthRepository{}, log)
+
+	router := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api", m.AuthMiddleware(), ok)
+	router.GET("/media", m.AuthMiddlewareMedia(), ok)
+
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"api with header", "/api", "Bearer " + validToken, http.StatusOK},
+		{"api with query token", "/api?token=" + validToken, "", http.StatusUnauthorized},
+		{"api with invalid header", "/api", "Bearer nope", http.StatusUnauthorized},
+		{"media with header", "/media", "Bearer " + validToken, http.StatusOK},
+		{"media with query token", "/media?token=" + validToken, "", http.StatusOK},
+		{"media with invalid query token", "/media?token=nope", "", http.StatusUnauthorized},
+		{"media without token", "/media", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			if res.Code != tt.want {
+				t.Errorf("status = %d, want %d", res.Code, tt.want)
+			}
+		})
+	}
+}
@@ -11,4 +11,28 @@ func ConfigMonitoringRoutes(g *gin.RouterGroup, h *handlers.CameraHandler, m mid
 	authGroup := g.Group("/monitoring")
 
 	authGroup.GET("/camera/details", m.AuthMiddleware(), h.GetCameraDetails())
+	authGroup.GET("/storage", m.AuthMiddleware(), h.GetStorageUsage())
+	authGroup.GET("/recordings", m.AuthMiddleware(), h.ListRecordings())
+	authGroup.GET("/recordings/:id/play", m.AuthMiddlewareMedia(), h.PlayRecording())
+	authGroup.GET("/recordings/:id/download", m.AuthMiddlewareMedia(), h.DownloadRecording())
+	authGroup.GET("/motion/events", m.AuthMiddleware(), h.ListMotionEvents())
+
+	// Every camera and its sub-resources
+	cameras := authGroup.Group("/cameras")
+	cameras.GET("", m.AuthMiddleware(), h.ListCameras())
+	cameras.POST("", m.AuthMiddleware(), h.AddCamera())
+	cameras.PATCH("/:id", m.AuthMiddleware(), h.UpdateCamera())
+	cameras.DELETE("/:id", m.AuthMiddleware(), h.RemoveCamera())
+	cameras.POST("/:id/restart", m.AuthMiddleware(), h.RestartCamera())
+	cameras.PUT("/:id/name", m.AuthMiddleware(), h.RenameCamera())
+	cameras.GET("/:id/snapshot", m.AuthMiddleware(), h.GetSnapshot())
+	cameras.GET("/:id/mjpeg", m.AuthMiddlewareMedia(), h.StreamMJPEG())
+	cameras.GET("/:id/hls/:file", m.AuthMiddlewareMedia(), h.ServeHLS())
+	cameras.POST("/:id/whep", m.AuthMiddleware(), h.WHEPOffer())
+	cameras.DELETE("/:id/whep/:session", m.AuthMiddleware(), h.WHEPHangup())
+	cameras.GET("/:id/recordings", m.AuthMiddleware(), h.ListRecordings())
+	cameras.GET("/:id/zones", m.AuthMiddleware(), h.ListMotionZones())
+	cameras.POST("/:id/zones", m.AuthMiddleware(), h.AddMotionZone())
+	cameras.PATCH("/:id/zones/:zone", m.AuthMiddleware(), h.UpdateMotionZone())
+	cameras.DELETE("/:id/zones/:zone", m.AuthMiddleware(), h.RemoveMotionZone())
 }
@@ -0,0 +1,316 @@
+package rtsp
+
+import (
+	"context"
+	"math/rand"
+	"monitoring-system/src/config"
+	"monitoring-system/src/factory"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/internal/modules/user-manager/domain/auth"
+	"monitoring-system/src/pkg/logger"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bluenviron/gortsplib/v4"
+	rtsp_auth "github.com/bluenviron/gortsplib/v4/pkg/auth"
+	"github.com/bluenviron/gortsplib/v4/pkg/base"
+	"github.com/bluenviron/gortsplib/v4/pkg/description"
+	"github.com/bluenviron/gortsplib/v4/pkg/format"
+	"github.com/bluenviron/gortsplib/v4/pkg/headers"
+)
+
+const (
+	AUTH_REALM = "monitoring-system"
+	// LOGIN_TIMEOUT bounds the password check of a single request
+	LOGIN_TIMEOUT = 5 * time.Second
+)
+
+// cameraStream republishes the JPEG frames of a camera as RTP/M-JPEG, the
+// frames are only fed while at least one session is playing.
+type cameraStream struct {
+	camera  camera.CameraService
+	format  *format.MJPEG
+	media   *description.Media
+	stream  *gortsplib.ServerStream
+	readers int
+	cancel  context.CancelFunc
+}
+
+// RTSPServer serves every camera at rtsp://host/<camera-id>, clients
+// authenticate with the credentials of the web interface through Basic
+// authentication, a JWT given as password or the token query parameter.
+type RTSPServer struct {
+	ctx      context.Context
+	logger   logger.Logger
+	config   *config.RTSPConfig
+	factory  *factory.Factory
+	server   *gortsplib.Server
+	mu       sync.Mutex
+	streams  map[string]*cameraStream
+	sessions map[*gortsplib.ServerSession]*cameraStream
+	// authorized caches the connections that already authenticated, so
+	// passwords are checked once per connection and not on every request
+	authorized map[*gortsplib.ServerConn]bool
+}
+
+func NewRTSPServer(ctx context.Context, logger logger.Logger, config *config.RTSPConfig, fac *factory.Factory) *RTSPServer {
+	return &RTSPServer{
+		ctx:        ctx,
+		logger:     logger,
+		config:     config,
+		factory:    fac,
+		streams:    make(map[string]*cameraStream),
+		sessions:   make(map[*gortsplib.ServerSession]*cameraStream),
+		authorized: make(map[*gortsplib.ServerConn]bool),
+	}
+}
+
+func (s *RTSPServer) Start() error {
+	s.logger.Info("Starting RTSP server %s", s.config.Address)
+
+	s.server = &gortsplib.Server{
+		Handler:     s,
+		RTSPAddress: s.config.Address,
+	}
+	return s.server.Start()
+}
+
+func (s *RTSPServer) Close() {
+	if s.server == nil {
+		return
+	}
+	s.logger.Info("Stopping RTSP server")
+
+	s.mu.Lock()
+	for id, cs := range s.streams {
+		s.closeStream(id, cs)
+	}
+	s.mu.Unlock()
+
+	s.server.Close()
+}
+
+func unauthorized() *base.Response {
+	return &base.Response{
+		StatusCode: base.StatusUnauthorized,
+		Header: base.Header{
+			"WWW-Authenticate": rtsp_auth.GenerateWWWAuthenticate([]rtsp_auth.ValidateMethod{rtsp_auth.ValidateMethodBasic}, AUTH_REALM, ""),
+		},
+	}
+}
+
+// validToken checks the token the same way the HTTP middleware does.
+func (s *RTSPServer) validToken(ctx context.Context, token string) bool {
+	if token == "" {
+		return false
+	}
+	claims, err := s.factory.UserManager.Infra.AuthService.ValidateToken(token)
+	if err != nil {
+		return false
+	}
+	_, err = s.factory.UserManager.Infra.AuthRepo.GetByUsername(ctx, claims.Username)
+	return err == nil
+}
+
+func (s *RTSPServer) authenticate(conn *gortsplib.ServerConn, req *base.Request, query string) *base.Response {
+	s.mu.Lock()
+	authorized := s.authorized[conn]
+	s.mu.Unlock()
+	if authorized {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(s.ctx, LOGIN_TIMEOUT)
+	defer cancel()
+
+	values, _ := url.ParseQuery(query)
+	authorized = s.validToken(ctx, values.Get("token"))
+
+	var header headers.Authorization
+	if !authorized && header.Unmarshal(req.Header["Authorization"]) == nil && header.Method == headers.AuthMethodBasic {
+		authorized = s.validToken(ctx, header.BasicPass)
+		if !authorized {
+			_, err := s.factory.UserManager.Infra.AuthService.Login(ctx, auth.LoginInput{
+				Username: header.BasicUser,
+				Password: header.BasicPass,
+			})
+			authorized = err == nil
+			if err != nil {
+				s.logger.Warning("RTSP authentication failed for user %s from %v", header.BasicUser, conn.NetConn().RemoteAddr())
+			}
+		}
+	}
+
+	if !authorized {
+		return unauthorized()
+	}
+
+	s.mu.Lock()
+	s.authorized[conn] = true
+	s.mu.Unlock()
+	return nil
+}
+
+// stream returns the stream of a camera, creating it on first use or when
+// the camera was restarted since the stream was created.
+func (s *RTSPServer) stream(path string) (*cameraStream, bool) {
+	id := strings.Trim(path, "/")
+	cam, ok := s.factory.Monitoring.CameraManager.GetCameras()[id]
+	if !ok || cam == nil {
+		return nil, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cs, ok := s.streams[id]; ok {
+		if cs.camera == cam {
+			return cs, true
+		}
+		s.closeStream(id, cs)
+	}
+
+	forma := &format.MJPEG{}
+	media := &description.Media{
+		Type:    description.MediaTypeVideo,
+		Formats: []format.Format{forma},
+	}
+	cs := &cameraStream{
+		camera: cam,
+		format: forma,
+		media:  media,
+		stream: gortsplib.NewServerStream(s.server, &description.Session{Medias: []*description.Media{media}}),
+	}
+	s.streams[id] = cs
+	return cs, true
+}
+
+// closeStream must be called with the lock held, closing the stream
+// disconnects its readers.
+func (s *RTSPServer) closeStream(id string, cs *cameraStream) {
+	if s.streams[id] == cs {
+		delete(s.streams, id)
+	}
+	if cs.cancel != nil {
+		cs.cancel()
+		cs.cancel = nil
+	}
+	cs.stream.Close()
+}
+
+func (s *RTSPServer) feed(ctx context.Context, id string, cs *cameraStream) {
+	subscription := cs.camera.Subscribe()
+	defer subscription.Close()
+
+	encoder, err := cs.format.CreateEncoder()
+	if err != nil {
+		s.logger.Error("Error creating RTSP encoder for camera %s: %v", id, err)
+		return
+	}
+
+	start := time.Now()
+	initialTimestamp := rand.Uint32()
+	clockRate := float64(cs.format.ClockRate())
+
+	for ctx.Err() == nil {
+		frame, err := subscription.Capture()
+		if err != nil {
+			s.logger.Info("Camera %s stopped, closing RTSP stream: %v", id, err)
+			s.mu.Lock()
+			if ctx.Err() == nil {
+				s.closeStream(id, cs)
+			}
+			s.mu.Unlock()
+			return
+		}
+
+		packets, err := encoder.Encode(frame)
+		if err != nil {
+			s.logger.Warning("Error encoding RTSP frame of camera %s: %v", id, err)
+			continue
+		}
+
+		timestamp := initialTimestamp + uint32(time.Since(start).Seconds()*clockRate)
+		for _, packet := range packets {
+			packet.Timestamp = timestamp
+			if err := cs.stream.WritePacketRTP(cs.media, packet); err != nil {
+				s.logger.Warning("Error writing RTSP packet of camera %s: %v", id, err)
+				break
+			}
+		}
+	}
+}
+
+func (s *RTSPServer) OnConnClose(ctx *gortsplib.ServerHandlerOnConnCloseCtx) {
+	s.mu.Lock()
+	delete(s.authorized, ctx.Conn)
+	s.mu.Unlock()
+}
+
+func (s *RTSPServer) OnSessionClose(ctx *gortsplib.ServerHandlerOnSessionCloseCtx) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs, ok := s.sessions[ctx.Session]
+	if !ok {
+		return
+	}
+	delete(s.sessions, ctx.Session)
+
+	cs.readers--
+	if cs.readers == 0 && cs.cancel != nil {
+		cs.cancel()
+		cs.cancel = nil
+	}
+}
+
+func (s *RTSPServer) OnDescribe(ctx *gortsplib.ServerHandlerOnDescribeCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	if res := s.authenticate(ctx.Conn, ctx.Request, ctx.Query); res != nil {
+		return res, nil, nil
+	}
+
+	cs, ok := s.stream(ctx.Path)
+	if !ok {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil, nil
+	}
+	return &base.Response{StatusCode: base.StatusOK}, cs.stream, nil
+}
+
+func (s *RTSPServer) OnSetup(ctx *gortsplib.ServerHandlerOnSetupCtx) (*base.Response, *gortsplib.ServerStream, error) {
+	if res := s.authenticate(ctx.Conn, ctx.Request, ctx.Query); res != nil {
+		return res, nil, nil
+	}
+
+	cs, ok := s.stream(ctx.Path)
+	if !ok {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil, nil
+	}
+	return &base.Response{StatusCode: base.StatusOK}, cs.stream, nil
+}
+
+func (s *RTSPServer) OnPlay(ctx *gortsplib.ServerHandlerOnPlayCtx) (*base.Response, error) {
+	id := strings.Trim(ctx.Path, "/")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cs, ok := s.streams[id]
+	if !ok {
+		return &base.Response{StatusCode: base.StatusNotFound}, nil
+	}
+	if _, playing := s.sessions[ctx.Session]; playing {
+		return &base.Response{StatusCode: base.StatusOK}, nil
+	}
+
+	s.sessions[ctx.Session] = cs
+	cs.readers++
+	if cs.cancel == nil {
+		feedCtx, cancel := context.WithCancel(s.ctx)
+		cs.cancel = cancel
+		go s.feed(feedCtx, id, cs)
+	}
+	s.logger.Info("RTSP client %v playing camera %s", ctx.Conn.NetConn().RemoteAddr(), id)
+	return &base.Response{StatusCode: base.StatusOK}, nil
+}
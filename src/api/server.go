@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 	"monitoring-system/src/api/gin_server"
+	"monitoring-system/src/api/rtsp"
 	"monitoring-system/src/config"
 	"monitoring-system/src/factory"
 	"monitoring-system/src/pkg/logger"
@@ -16,6 +17,8 @@ type Server struct {
 	config     *config.Config
 	gin_server *gin_server.Gin
 	server     *http.Server
+	rtsp       *rtsp.RTSPServer
+	factory    *factory.Factory
 	validator  validator.Validator
 }
 
@@ -26,6 +29,7 @@ func New(config *config.Config, logger logger.Logger, factory *factory.Factory)
 		config:     config,
 		gin_server: gin,
 		log:        logger,
+		factory:    factory,
 		validator:  validator.NewValidatorImpl(),
 	}
 }
@@ -37,10 +41,22 @@ func (s *Server) Start(ctx context.Context, staticFilesPath string) error {
 	s.gin_server.SetupMiddlewares()
 	s.gin_server.SetupApi(ctx, staticFilesPath)
 
+	if s.config.RTSP.Enabled {
+		s.rtsp = rtsp.NewRTSPServer(ctx, s.log, &s.config.RTSP, s.factory)
+		if err := s.rtsp.Start(); err != nil {
+			s.log.Error("Error starting RTSP server: %v", err)
+			s.rtsp = nil
+		}
+	}
+
 	go func() {
 		<-ctx.Done()
 		s.log.Info("Shutdown Server ...")
 
+		if s.rtsp != nil {
+			s.rtsp.Close()
+		}
+
 		if err := s.server.Shutdown(ctx); err != nil {
 			s.log.Error("Server Shutdown: %v", err)
 		}
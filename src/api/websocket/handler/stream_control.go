@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"fmt"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"time"
+)
+
+const (
+	MAX_STREAM_FPS     = 30
+	MIN_STREAM_QUALITY = 20
+	MAX_STREAM_SIZE    = 4096
+	// Adaptation only looks at the writes of the last ADAPT_INTERVAL
+	ADAPT_INTERVAL = 2 * time.Second
+	// Writes taking more than this share of the frame interval mean the
+	// client is falling behind, below FAST_WRITE_RATIO it has room to spare
+	SLOW_WRITE_RATIO = 0.5
+	FAST_WRITE_RATIO = 0.1
+	QUALITY_STEP     = 10
+)
+
+// ControlMessage is sent by clients as a JSON text message. Settings only
+// change the fields present, a width and height of 0 restore the camera
+// size. Pause and resume take no fields.
+type ControlMessage struct {
+	Action   string `json:"action"`
+	FPS      int    `json:"fps,omitempty"`
+	Width    *int   `json:"width,omitempty"`
+	Height   *int   `json:"height,omitempty"`
+	Quality  int    `json:"quality,omitempty"`
+	Adaptive *bool  `json:"adaptive,omitempty"`
+}
+
+// StreamState is sent back as a JSON text message after every change, FPS
+// and Quality are the values in use, which adaptation may lower below the
+// requested ones.
+type StreamState struct {
+	Type             string `json:"type"`
+	FPS              int    `json:"fps"`
+	Width            int    `json:"width"`
+	Height           int    `json:"height"`
+	Quality          int    `json:"quality"`
+	RequestedFPS     int    `json:"requested_fps"`
+	RequestedQuality int    `json:"requested_quality"`
+	Paused           bool   `json:"paused"`
+	Adaptive         bool   `json:"adaptive"`
+	Error            string `json:"error,omitempty"`
+}
+
+// streamControl holds the settings of a single websocket stream and lowers
+// them while writes are slow, the frame rate first since frames at the
+// camera quality are shared with the other viewers, then the quality.
+type streamControl struct {
+	requestedFPS     int
+	requestedQuality int
+	fps              int
+	quality          int
+	width            int
+	height           int
+	paused           bool
+	adaptive         bool
+	defaultQuality   int
+	writes           int
+	writeTime        time.Duration
+}
+
+func newStreamControl(defaultQuality int) *streamControl {
+	return &streamControl{
+		requestedFPS:     FPS_STREAM_LIMIT,
+		requestedQuality: defaultQuality,
+		fps:              FPS_STREAM_LIMIT,
+		quality:          defaultQuality,
+		adaptive:         true,
+		defaultQuality:   defaultQuality,
+	}
+}
+
+func (s *streamControl) apply(msg ControlMessage) error {
+	switch msg.Action {
+	case "pause":
+		s.paused = true
+	case "resume":
+		s.paused = false
+	case "settings":
+		if msg.FPS < 0 || msg.FPS > MAX_STREAM_FPS {
+			return fmt.Errorf("fps must be between 1 and %d", MAX_STREAM_FPS)
+		}
+		if msg.Quality < 0 || msg.Quality > 100 {
+			return fmt.Errorf("quality must be between 1 and 100")
+		}
+		for _, size := range []*int{msg.Width, msg.Height} {
+			if size != nil && (*size < 0 || *size > MAX_STREAM_SIZE) {
+				return fmt.Errorf("width and height must be between 0 and %d", MAX_STREAM_SIZE)
+			}
+		}
+
+		if msg.FPS > 0 {
+			s.requestedFPS = msg.FPS
+		}
+		if msg.Quality > 0 {
+			s.requestedQuality = msg.Quality
+		}
+		if msg.Width != nil {
+			s.width = *msg.Width
+		}
+		if msg.Height != nil {
+			s.height = *msg.Height
+		}
+		if msg.Adaptive != nil {
+			s.adaptive = *msg.Adaptive
+		}
+		s.fps, s.quality = s.requestedFPS, s.requestedQuality
+	default:
+		return fmt.Errorf("unknown action %q", msg.Action)
+	}
+
+	s.resetWrites()
+	return nil
+}
+
+func (s *streamControl) interval() time.Duration {
+	return time.Second / time.Duration(s.fps)
+}
+
+// snapshotOptions is empty when the shared frames of the camera can be sent
+// as they are.
+func (s *streamControl) snapshotOptions() camera.SnapshotOptions {
+	opts := camera.SnapshotOptions{Width: s.width, Height: s.height}
+	if s.quality != s.defaultQuality {
+		opts.Quality = s.quality
+	}
+	return opts
+}
+
+func (s *streamControl) recordWrite(d time.Duration) {
+	s.writes++
+	s.writeTime += d
+}
+
+func (s *streamControl) resetWrites() {
+	s.writes = 0
+	s.writeTime = 0
+}
+
+// adapt reports whether the settings changed since the last call.
+func (s *streamControl) adapt() bool {
+	defer s.resetWrites()
+	if !s.adaptive || s.paused || s.writes == 0 {
+		return false
+	}
+
+	ratio := float64(s.writeTime/time.Duration(s.writes)) / float64(s.interval())
+
+	switch {
+	case ratio > SLOW_WRITE_RATIO && s.fps > 1:
+		s.fps = max(1, s.fps*3/4)
+	case ratio > SLOW_WRITE_RATIO && s.quality > MIN_STREAM_QUALITY:
+		s.quality = max(MIN_STREAM_QUALITY, s.quality-QUALITY_STEP)
+	case ratio < FAST_WRITE_RATIO && s.quality < s.requestedQuality:
+		s.quality = min(s.requestedQuality, s.quality+QUALITY_STEP)
+	case ratio < FAST_WRITE_RATIO && s.fps < s.requestedFPS:
+		s.fps = min(s.requestedFPS, s.fps+1)
+	default:
+		return false
+	}
+	return true
+}
+
+func (s *streamControl) state() StreamState {
+	return StreamState{
+		Type:             "state",
+		FPS:              s.fps,
+		Width:            s.width,
+		Height:           s.height,
+		Quality:          s.quality,
+		RequestedFPS:     s.requestedFPS,
+		RequestedQuality: s.requestedQuality,
+		Paused:           s.paused,
+		Adaptive:         s.adaptive,
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"monitoring-system/src/internal/modules/monitoring/domain/camera"
 	"monitoring-system/src/pkg/logger"
 	"net/http"
@@ -11,7 +12,9 @@ import (
 )
 
 const (
-	FPS_STREAM_LIMIT = 10
+	// FPS_STREAM_LIMIT is the frame rate until the client asks for another
+	FPS_STREAM_LIMIT      = 10
+	CONTROL_MESSAGE_LIMIT = 4096
 )
 
 var WsUpgrader = websocket.Upgrader{
@@ -38,6 +41,34 @@ func NewVideoHandler(ctx context.Context, cam camera.CameraService, logger logge
 	}
 }
 
+// readControls forwards the control messages of the client until the
+// connection is closed, which stops the stream.
+func (wss *videoHandler) readControls(ctx context.Context, cancel context.CancelFunc, conn *websocket.Conn, controls chan<- ControlMessage) {
+	defer cancel()
+	conn.SetReadLimit(CONTROL_MESSAGE_LIMIT)
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		if messageType != websocket.TextMessage {
+			continue
+		}
+
+		var msg ControlMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			msg = ControlMessage{Action: "invalid"}
+		}
+
+		select {
+		case controls <- msg:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func (wss *videoHandler) streamVideo(ctx context.Context, cam camera.CameraService, conn *websocket.Conn) {
 	defer func() {
 		if r := recover(); r != nil {
@@ -48,10 +79,40 @@ func (wss *videoHandler) streamVideo(ctx context.Context, cam camera.CameraServi
 
 	defer conn.Close()
 
-	frameInterval := time.Second / time.Duration(FPS_STREAM_LIMIT)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	controls := make(chan ControlMessage)
+	go wss.readControls(ctx, cancel, conn, controls)
 
-	ticker := time.NewTicker(frameInterval)
+	// Frames at the camera settings come from the shared subscription, the
+	// others are encoded for this client alone
+	var sub camera.FrameSubscription
+	defer func() {
+		if sub != nil {
+			sub.Close()
+		}
+	}()
+	capture := func(opts camera.SnapshotOptions) ([]byte, error) {
+		if opts == (camera.SnapshotOptions{}) {
+			if sub == nil {
+				sub = cam.Subscribe()
+			}
+			return sub.Capture()
+		}
+		if sub != nil {
+			sub.Close()
+			sub = nil
+		}
+		return cam.Snapshot(ctx, opts)
+	}
+
+	stream := newStreamControl(cam.GetDetails().Settings.JPEGQuality)
+
+	ticker := time.NewTicker(stream.interval())
 	defer ticker.Stop()
+	adapt := time.NewTicker(ADAPT_INTERVAL)
+	defer adapt.Stop()
 
 	for {
 		select {
@@ -59,22 +120,52 @@ func (wss *videoHandler) streamVideo(ctx context.Context, cam camera.CameraServi
 			return
 		case <-cam.Done():
 			return
+		case msg := <-controls:
+			state := stream.state()
+			if err := stream.apply(msg); err != nil {
+				state.Error = err.Error()
+			} else {
+				state = stream.state()
+				ticker.Reset(stream.interval())
+				if stream.paused && sub != nil {
+					sub.Close()
+					sub = nil
+				}
+			}
+			if err := conn.WriteJSON(state); err != nil {
+				return
+			}
+		case <-adapt.C:
+			if !stream.adapt() {
+				continue
+			}
+			wss.logger.Info("Adapting stream of camera %s to %d FPS and quality %d", wss.camera.GetDetails().ID, stream.fps, stream.quality)
+			ticker.Reset(stream.interval())
+			if err := conn.WriteJSON(stream.state()); err != nil {
+				return
+			}
 		case <-ticker.C:
-			img, err := cam.Capture()
+			if stream.paused {
+				continue
+			}
+			img, err := capture(stream.snapshotOptions())
 			if err != nil {
-				wss.logger.Error("Error capturing image from camera %d: %v", wss.camera.GetDetails().ID, err)
+				wss.logger.Error("Error capturing image from camera %s: %v", wss.camera.GetDetails().ID, err)
 				continue
 			}
 			if len(img) == 0 {
-				wss.logger.Error("Empty image captured from camera %d", wss.camera.GetDetails().ID)
+				wss.logger.Error("Empty image captured from camera %s", wss.camera.GetDetails().ID)
 				continue
 			}
+
+			start := time.Now()
 			err = conn.WriteMessage(websocket.BinaryMessage, img)
 			if err != nil {
-				wss.logger.Error("Error sending image through WebSocket for camera %d: %v", wss.camera.GetDetails().ID, err)
+				wss.logger.Error("Error sending image through WebSocket for camera %s: %v", wss.camera.GetDetails().ID, err)
 				conn.Close()
 				return
 			}
+			stream.recordWrite(time.Since(start))
 		}
 	}
 }
@@ -56,7 +56,7 @@ func main() {
 		cancel()
 	}()
 
-	factory, err := factory.NewFactory(ctx, logger, db, appConfig)
+	factory, err := factory.NewFactory(ctx, logger, db, appConfig, *saveData)
 	if err != nil {
 		logger.Error("Error creating factory %v", err)
 		return
@@ -82,5 +82,11 @@ func main() {
 	wg.Wait()
 
 	<-ctx.Done()
+
+	factory.Monitoring.HLS.Close()
+	factory.Monitoring.WebRTC.Close()
+	if err := factory.Monitoring.CameraManager.Close(); err != nil {
+		logger.Error("Error closing camera manager %v", err)
+	}
 	os.Exit(0)
 }
@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -11,43 +12,237 @@ type ApiConfig struct {
 	Port int    `mapstructure:"port"`
 }
 
+// StreamConfig is a camera declared in the config. Type defaults to a
+// network stream, URL then holds the stream URL; it holds the directory of
+// "images" sources and, for "virtual" cameras, the pattern (bars or clock) or
+// the video file to loop.
 type StreamConfig struct {
-	URL        string `mapstructure:"url"`
-	StreamName string `mapstructure:"stream_name"`
+	Type           string `mapstructure:"type"`
+	URL            string `mapstructure:"url"`
+	StreamName     string `mapstructure:"stream_name"`
+	CameraOverride `mapstructure:",squash"`
+}
+
+type RecordingConfig struct {
+	Mode             string        `mapstructure:"mode"`
+	Path             string        `mapstructure:"path"`
+	PreRoll          time.Duration `mapstructure:"pre_roll"`
+	PostRoll         time.Duration `mapstructure:"post_roll"`
+	SegmentDuration  time.Duration `mapstructure:"segment_duration"`
+	MaxSegmentSizeMB int64         `mapstructure:"max_segment_size_mb"`
+}
+
+// MotionConfig tunes the motion detector, zero values keep the defaults of
+// the algorithm.
+type MotionConfig struct {
+	Algorithm     string  `mapstructure:"algorithm"`
+	History       int     `mapstructure:"history"`
+	VarThreshold  float64 `mapstructure:"var_threshold"`
+	DetectShadows *bool   `mapstructure:"detect_shadows"`
+	Threshold     int     `mapstructure:"threshold"`
+	KernelSize    int     `mapstructure:"kernel_size"`
+	MinFrames     int     `mapstructure:"min_frames"`
+}
+
+// WithOverride returns a copy of the configuration with the non zero values
+// of override applied.
+func (c MotionConfig) WithOverride(override MotionConfig) MotionConfig {
+	if override.Algorithm != "" {
+		c.Algorithm = override.Algorithm
+	}
+	if override.History > 0 {
+		c.History = override.History
+	}
+	if override.VarThreshold > 0 {
+		c.VarThreshold = override.VarThreshold
+	}
+	if override.DetectShadows != nil {
+		c.DetectShadows = override.DetectShadows
+	}
+	if override.Threshold > 0 {
+		c.Threshold = override.Threshold
+	}
+	if override.KernelSize > 0 {
+		c.KernelSize = override.KernelSize
+	}
+	if override.MinFrames > 0 {
+		c.MinFrames = override.MinFrames
+	}
+	return c
+}
+
+// CameraOverride holds the settings of a single camera, zero values keep the
+// global ones.
+type CameraOverride struct {
+	Width         int          `mapstructure:"width"`
+	Height        int          `mapstructure:"height"`
+	FPS           int          `mapstructure:"fps"`
+	Codec         string       `mapstructure:"codec"`
+	JPEGQuality   int          `mapstructure:"jpeg_quality"`
+	MinArea       int          `mapstructure:"min_area"`
+	RecordingMode string       `mapstructure:"recording_mode"`
+	Overlay       *bool        `mapstructure:"overlay"`
+	Loop          *bool        `mapstructure:"loop"`
+	Motion        MotionConfig `mapstructure:"motion"`
 }
 
 type CameraConfig struct {
-	FPS                int            `mapstructure:"fps"`
-	Width              int            `mapstructure:"width"`
-	Height             int            `mapstructure:"height"`
-	Codec              string         `mapstructure:"codec"`
-	MotionDetection    bool           `mapstructure:"motion_detection"`
-	MinArea            int            `mapstructure:"min_area"`
-	CheckSystemCameras bool           `mapstructure:"check_system_cameras"`
-	Stream             []StreamConfig `mapstructure:"stream"`
+	FPS                 int                       `mapstructure:"fps"`
+	Width               int                       `mapstructure:"width"`
+	Height              int                       `mapstructure:"height"`
+	Codec               string                    `mapstructure:"codec"`
+	MotionDetection     bool                      `mapstructure:"motion_detection"`
+	MinArea             int                       `mapstructure:"min_area"`
+	MotionEndDelay      time.Duration             `mapstructure:"motion_end_delay"`
+	JPEGQuality         int                       `mapstructure:"jpeg_quality"`
+	Overlay             bool                      `mapstructure:"overlay"`
+	Loop                bool                      `mapstructure:"loop"`
+	Motion              MotionConfig              `mapstructure:"motion"`
+	CheckSystemCameras  bool                      `mapstructure:"check_system_cameras"`
+	ReconnectInterval   time.Duration             `mapstructure:"reconnect_interval"`
+	ReconnectMaxBackoff time.Duration             `mapstructure:"reconnect_max_backoff"`
+	Stream              []StreamConfig            `mapstructure:"stream"`
+	Recording           RecordingConfig           `mapstructure:"recording"`
+	Cameras             map[string]CameraOverride `mapstructure:"cameras"`
+}
+
+// WithOverride returns a copy of the configuration with the non zero values
+// of override applied.
+func (c CameraConfig) WithOverride(override CameraOverride) CameraConfig {
+	if override.Width > 0 {
+		c.Width = override.Width
+	}
+	if override.Height > 0 {
+		c.Height = override.Height
+	}
+	if override.FPS > 0 {
+		c.FPS = override.FPS
+	}
+	if override.Codec != "" {
+		c.Codec = override.Codec
+	}
+	if override.JPEGQuality > 0 {
+		c.JPEGQuality = override.JPEGQuality
+	}
+	if override.MinArea > 0 {
+		c.MinArea = override.MinArea
+	}
+	if override.RecordingMode != "" {
+		c.Recording.Mode = override.RecordingMode
+	}
+	if override.Overlay != nil {
+		c.Overlay = *override.Overlay
+	}
+	if override.Loop != nil {
+		c.Loop = *override.Loop
+	}
+	c.Motion = c.Motion.WithOverride(override.Motion)
+	return c
+}
+
+// ForCamera merges the overrides of the given camera over the global
+// configuration.
+func (c CameraConfig) ForCamera(cameraID string) CameraConfig {
+	return c.WithOverride(c.Cameras[cameraID])
+}
+
+type RetentionConfig struct {
+	MaxAge        time.Duration `mapstructure:"max_age"`
+	CameraQuotaMB int64         `mapstructure:"camera_quota_mb"`
+	MinFreeMB     int64         `mapstructure:"min_free_mb"`
+	CheckInterval time.Duration `mapstructure:"check_interval"`
+}
+
+// HLSConfig configures the HLS output, packaged by ffmpeg only while someone
+// is watching.
+type HLSConfig struct {
+	Enabled         bool          `mapstructure:"enabled"`
+	Path            string        `mapstructure:"path"`
+	FFmpegPath      string        `mapstructure:"ffmpeg_path"`
+	SegmentDuration time.Duration `mapstructure:"segment_duration"`
+	PlaylistSize    int           `mapstructure:"playlist_size"`
+	IdleTimeout     time.Duration `mapstructure:"idle_timeout"`
+}
+
+// RTSPConfig configures the embedded RTSP server republishing the cameras.
+type RTSPConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Address string `mapstructure:"address"`
+}
+
+// WebRTCConfig configures the WHEP endpoint, cameras are encoded to H.264 by
+// ffmpeg only while someone is watching.
+type WebRTCConfig struct {
+	Enabled          bool          `mapstructure:"enabled"`
+	FFmpegPath       string        `mapstructure:"ffmpeg_path"`
+	ICEServers       []string      `mapstructure:"ice_servers"`
+	KeyframeInterval time.Duration `mapstructure:"keyframe_interval"`
 }
 
 type Config struct {
-	Api    ApiConfig    `mapstructure:"api"`
-	JwtKey string       `mapstructure:"jwt_key"`
-	Camera CameraConfig `mapstructure:"camera"`
+	Api       ApiConfig       `mapstructure:"api"`
+	JwtKey    string          `mapstructure:"jwt_key"`
+	Camera    CameraConfig    `mapstructure:"camera"`
+	Retention RetentionConfig `mapstructure:"retention"`
+	HLS       HLSConfig       `mapstructure:"hls"`
+	RTSP      RTSPConfig      `mapstructure:"rtsp"`
+	WebRTC    WebRTCConfig    `mapstructure:"webrtc"`
 }
 
+// setDefaults registers every default under its own key. viper does not merge
+// a struct default with a section read from the file, so a config.yaml with a
+// camera section would otherwise zero every camera field it does not list.
 func setDefaults() {
 	viper.SetDefault("api.host", "0.0.0.0")
 	viper.SetDefault("api.port", 4000)
 	viper.SetDefault("jwt_key", "SET_ME")
-	viper.SetDefault("camera", CameraConfig{
-		FPS:                15,
-		Width:              640,
-		Height:             480,
-		Codec:              "MJPG",
-		MotionDetection:    true,
-		MinArea:            4000,
-		CheckSystemCameras: true,
-		Stream:             []StreamConfig{},
-	})
 
+	viper.SetDefault("camera.fps", 15)
+	viper.SetDefault("camera.width", 640)
+	viper.SetDefault("camera.height", 480)
+	viper.SetDefault("camera.codec", "MJPG")
+	viper.SetDefault("camera.motion_detection", true)
+	viper.SetDefault("camera.min_area", 4000)
+	viper.SetDefault("camera.motion_end_delay", 3*time.Second)
+	viper.SetDefault("camera.jpeg_quality", 75)
+	viper.SetDefault("camera.overlay", true)
+	viper.SetDefault("camera.loop", true)
+	viper.SetDefault("camera.motion.algorithm", "mog2")
+	viper.SetDefault("camera.motion.history", 500)
+	viper.SetDefault("camera.motion.threshold", 25)
+	viper.SetDefault("camera.motion.kernel_size", 3)
+	viper.SetDefault("camera.motion.min_frames", 1)
+	viper.SetDefault("camera.check_system_cameras", true)
+	viper.SetDefault("camera.reconnect_interval", 5*time.Second)
+	viper.SetDefault("camera.reconnect_max_backoff", 5*time.Minute)
+	viper.SetDefault("camera.stream", []StreamConfig{})
+	viper.SetDefault("camera.recording.mode", "motion")
+	viper.SetDefault("camera.recording.path", "recordings")
+	viper.SetDefault("camera.recording.pre_roll", 3*time.Second)
+	viper.SetDefault("camera.recording.post_roll", 5*time.Second)
+	viper.SetDefault("camera.recording.segment_duration", 5*time.Minute)
+	viper.SetDefault("camera.recording.max_segment_size_mb", 100)
+	viper.SetDefault("camera.cameras", map[string]CameraOverride{})
+
+	viper.SetDefault("retention.max_age", 7*24*time.Hour)
+	viper.SetDefault("retention.camera_quota_mb", 0)
+	viper.SetDefault("retention.min_free_mb", 1024)
+	viper.SetDefault("retention.check_interval", 5*time.Minute)
+
+	viper.SetDefault("hls.enabled", true)
+	viper.SetDefault("hls.path", "")
+	viper.SetDefault("hls.ffmpeg_path", "ffmpeg")
+	viper.SetDefault("hls.segment_duration", 2*time.Second)
+	viper.SetDefault("hls.playlist_size", 6)
+	viper.SetDefault("hls.idle_timeout", 30*time.Second)
+
+	viper.SetDefault("rtsp.enabled", true)
+	viper.SetDefault("rtsp.address", ":8554")
+
+	viper.SetDefault("webrtc.enabled", true)
+	viper.SetDefault("webrtc.ffmpeg_path", "ffmpeg")
+	viper.SetDefault("webrtc.ice_servers", []string{})
+	viper.SetDefault("webrtc.keyframe_interval", time.Second)
 }
 
 func LoadConfig(configPath string) (*Config, error) {
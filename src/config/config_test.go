@@ -0,0 +1,100 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func loadConfig(t *testing.T, dir string) *Config {
+	t.Helper()
+	viper.Reset()
+	t.Cleanup(viper.Reset)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	return cfg
+}
+
+func TestLoadConfigKeepsDefaultsOfPartialSections(t *testing.T) {
+	dir := t.TempDir()
+	content := "camera:\n  fps: 10\n  stream: []\nrtsp:\n  address: \":9554\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := loadConfig(t, dir)
+
+	if cfg.Camera.FPS != 10 {
+		t.Errorf("FPS = %d, want 10 from the file", cfg.Camera.FPS)
+	}
+	if cfg.Camera.Recording.Mode != "motion" {
+		t.Errorf("Recording.Mode = %q, want motion", cfg.Camera.Recording.Mode)
+	}
+	if cfg.Camera.Recording.SegmentDuration != 5*time.Minute {
+		t.Errorf("Recording.SegmentDuration = %v, want 5m", cfg.Camera.Recording.SegmentDuration)
+	}
+	if cfg.Camera.Recording.PreRoll != 3*time.Second || cfg.Camera.Recording.PostRoll != 5*time.Second {
+		t.Errorf("pre/post roll = %v/%v, want 3s/5s", cfg.Camera.Recording.PreRoll, cfg.Camera.Recording.PostRoll)
+	}
+	if cfg.Camera.JPEGQuality != 75 {
+		t.Errorf("JPEGQuality = %d, want 75", cfg.Camera.JPEGQuality)
+	}
+	if !cfg.Camera.Overlay || !cfg.Camera.MotionDetection || !cfg.Camera.CheckSystemCameras {
+		t.Errorf("Overlay, MotionDetection and CheckSystemCameras should default to true")
+	}
+	if cfg.Camera.ReconnectMaxBackoff != 5*time.Minute {
+		t.Errorf("ReconnectMaxBackoff = %v, want 5m", cfg.Camera.ReconnectMaxBackoff)
+	}
+	if cfg.Camera.Motion.Threshold != 25 || cfg.Camera.Motion.KernelSize != 3 {
+		t.Errorf("Motion = %+v, want threshold 25 and kernel size 3", cfg.Camera.Motion)
+	}
+	if !cfg.RTSP.Enabled || cfg.RTSP.Address != ":9554" {
+		t.Errorf("RTSP = %+v, want enabled on :9554", cfg.RTSP)
+	}
+	if cfg.HLS.PlaylistSize != 6 {
+		t.Errorf("HLS.PlaylistSize = %d, want 6", cfg.HLS.PlaylistSize)
+	}
+}
+
+func TestLoadConfigWritesDefaults(t *testing.T) {
+	dir := t.TempDir()
+	loadConfig(t, dir)
+
+	if _, err := os.Stat(filepath.Join(dir, "config.yaml")); err != nil {
+		t.Fatalf("default config not written: %v", err)
+	}
+
+	// The written file is read back on the next start
+	cfg := loadConfig(t, dir)
+	if cfg.Camera.Recording.Mode != "motion" || cfg.Camera.Recording.SegmentDuration != 5*time.Minute {
+		t.Errorf("Recording = %+v, want the defaults", cfg.Camera.Recording)
+	}
+	if cfg.Retention.MaxAge != 7*24*time.Hour {
+		t.Errorf("Retention.MaxAge = %v, want 168h", cfg.Retention.MaxAge)
+	}
+}
+
+func TestForCameraAppliesRecordingMode(t *testing.T) {
+	dir := t.TempDir()
+	content := "camera:\n  stream: []\n  cameras:\n    cam1:\n      recording_mode: always\n      fps: 5\n"
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := loadConfig(t, dir)
+
+	cam1 := cfg.Camera.ForCamera("cam1")
+	if cam1.Recording.Mode != "always" || cam1.FPS != 5 {
+		t.Errorf("cam1 mode/fps = %q/%d, want always/5", cam1.Recording.Mode, cam1.FPS)
+	}
+	other := cfg.Camera.ForCamera("cam2")
+	if other.Recording.Mode != "motion" || other.FPS != 15 {
+		t.Errorf("cam2 mode/fps = %q/%d, want the global motion/15", other.Recording.Mode, other.FPS)
+	}
+}
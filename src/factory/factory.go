@@ -4,11 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	camera_infra "monitoring-system/src/internal/modules/monitoring/infra/camera"
+	motion_infra "monitoring-system/src/internal/modules/monitoring/infra/motion"
+	recording_infra "monitoring-system/src/internal/modules/monitoring/infra/recording"
 	monitoring_use_cases "monitoring-system/src/internal/modules/monitoring/usecases"
 	"monitoring-system/src/internal/modules/user-manager/domain/auth"
 	auth_infra "monitoring-system/src/internal/modules/user-manager/infra/auth"
 	user_manager_use_cases "monitoring-system/src/internal/modules/user-manager/usecases"
 	"monitoring-system/src/pkg/logger"
+	"path/filepath"
 )
 
 type Factory struct {
@@ -27,10 +34,24 @@ type UserManagerInfra struct {
 }
 
 type Monitoring struct {
+	Infra         MonitoringInfra
 	CameraManager monitoring_use_cases.CameraManager
+	Recorder      monitoring_use_cases.Recorder
+	Retention     monitoring_use_cases.RetentionManager
+	Motion        monitoring_use_cases.MotionManager
+	HLS           monitoring_use_cases.HLSManager
+	WebRTC        monitoring_use_cases.WebRTCManager
 	UseCases      *monitoring_use_cases.MonitoringUseCases
 }
 
+type MonitoringInfra struct {
+	CameraRepo      camera.Repository
+	Storage         recording.Storage
+	RecordingRepo   recording.Repository
+	MotionEventRepo motion.EventRepository
+	MotionZoneRepo  motion.ZoneRepository
+}
+
 func NewUserManager(ctx context.Context, logger logger.Logger, sqlDb *sql.DB, config *config.Config) (*UserManager, error) {
 	authRepo, err := auth_infra.NewAuthRepository(ctx, sqlDb, logger)
 	if err != nil {
@@ -53,27 +74,90 @@ func NewUserManager(ctx context.Context, logger logger.Logger, sqlDb *sql.DB, co
 	}, nil
 }
 
-func NewMonitoring(ctx context.Context, logger logger.Logger, config *config.Config) (*Monitoring, error) {
-	monitoring, err := monitoring_use_cases.NewCameraManager(ctx, logger, &config.Camera)
+func NewMonitoring(ctx context.Context, logger logger.Logger, sqlDb *sql.DB, config *config.Config, dataPath string) (*Monitoring, error) {
+	recordingRepo, err := recording_infra.NewRecordingRepository(ctx, sqlDb, logger)
+	if err != nil {
+		logger.Error("Error creating recording repository %v", err)
+		return nil, err
+	}
+
+	motionEventRepo, err := motion_infra.NewEventRepository(ctx, sqlDb, logger)
+	if err != nil {
+		logger.Error("Error creating motion event repository %v", err)
+		return nil, err
+	}
+
+	motionZoneRepo, err := motion_infra.NewZoneRepository(ctx, sqlDb, logger)
+	if err != nil {
+		logger.Error("Error creating motion zone repository %v", err)
+		return nil, err
+	}
+
+	cameraRepo, err := camera_infra.NewCameraRepository(ctx, sqlDb, logger)
+	if err != nil {
+		logger.Error("Error creating camera repository %v", err)
+		return nil, err
+	}
+
+	storage, err := recording_infra.NewFileStorage(filepath.Join(dataPath, config.Camera.Recording.Path), logger)
+	if err != nil {
+		logger.Error("Error creating recordings storage %v", err)
+		return nil, err
+	}
+
+	recorder, err := monitoring_use_cases.NewRecorder(ctx, logger, &config.Camera, storage.Path(), recordingRepo)
+	if err != nil {
+		logger.Error("Error creating monitoring recorder %v", err)
+		return nil, err
+	}
+
+	motionManager := monitoring_use_cases.NewMotionManager(ctx, logger, &config.Camera, motionEventRepo, motionZoneRepo)
+
+	monitoring, err := monitoring_use_cases.NewCameraManager(ctx, logger, &config.Camera, recorder, motionManager, cameraRepo, monitoring_use_cases.NewCamera)
 	if err != nil {
 		logger.Error("Error creating monitoring camera manager %v", err)
 		return nil, err
 	}
-	monitoringUseCases := monitoring_use_cases.NewMonitoringUseCases(logger, monitoring)
+
+	recordings := monitoring_use_cases.NewRecordingsUseCase(recordingRepo, storage, logger)
+	if err := recordings.SyncCatalog(ctx); err != nil {
+		logger.Error("Error syncing recordings catalog %v", err)
+	}
+
+	retention := monitoring_use_cases.NewRetentionManager(logger, &config.Retention, storage, recordingRepo)
+	retention.Start(ctx)
+
+	hls := monitoring_use_cases.NewHLSManager(ctx, logger, &config.HLS, monitoring)
+
+	webrtc := monitoring_use_cases.NewWebRTCManager(ctx, logger, &config.WebRTC, monitoring)
+
+	monitoringUseCases := monitoring_use_cases.NewMonitoringUseCases(logger, monitoring, retention, recordings, motionManager, hls, webrtc)
 
 	return &Monitoring{
+		Infra: MonitoringInfra{
+			CameraRepo:      cameraRepo,
+			Storage:         storage,
+			RecordingRepo:   recordingRepo,
+			MotionEventRepo: motionEventRepo,
+			MotionZoneRepo:  motionZoneRepo,
+		},
 		CameraManager: monitoring,
+		Recorder:      recorder,
+		Retention:     retention,
+		Motion:        motionManager,
+		HLS:           hls,
+		WebRTC:        webrtc,
 		UseCases:      monitoringUseCases,
 	}, nil
 }
 
-func NewFactory(ctx context.Context, logger logger.Logger, sqlDb *sql.DB, config *config.Config) (*Factory, error) {
+func NewFactory(ctx context.Context, logger logger.Logger, sqlDb *sql.DB, config *config.Config, dataPath string) (*Factory, error) {
 	userManager, err := NewUserManager(ctx, logger, sqlDb, config)
 	if err != nil {
 		return nil, err
 	}
 
-	monitoring, err := NewMonitoring(ctx, logger, config)
+	monitoring, err := NewMonitoring(ctx, logger, sqlDb, config, dataPath)
 	if err != nil {
 		return nil, err
 	}
@@ -2,21 +2,84 @@ package camera
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	"path/filepath"
+	"strconv"
+	"time"
 )
 
 type CameraService interface {
 	Start() error
 	Close() error
-	RecordVideo(ctx context.Context, filename string, motionOnly bool) error
+	RecordVideo(ctx context.Context, opts recording.Options) error
+	DetectMotion(ctx context.Context, onDetection func(motion.Detection)) error
+	// SetMotionZones replaces the zones used by motion detection and motion
+	// recording, running detectors pick them up on the next frame.
+	SetMotionZones(zones []motion.Zone)
 	Capture() ([]byte, error)
+	Snapshot(ctx context.Context, opts SnapshotOptions) ([]byte, error)
+	Subscribe() FrameSubscription
 	Done() <-chan struct{}
 	GetDetails() CameraDetails
 }
 
+// FrameSubscription delivers the frames of a camera to a single consumer
+// without competing with the other consumers of the same camera.
+type FrameSubscription interface {
+	Capture() ([]byte, error)
+	Close()
+}
+
+// SnapshotOptions customize a single JPEG frame, zero values keep the frame
+// size and the camera JPEG quality. Setting only one dimension keeps the
+// aspect ratio.
+type SnapshotOptions struct {
+	Width   int
+	Height  int
+	Quality int
+}
+
+type State string
+
+const (
+	StateStarting     State = "starting"
+	StateStreaming    State = "streaming"
+	StateDegraded     State = "degraded"
+	StateReconnecting State = "reconnecting"
+	StateStopped      State = "stopped"
+	StateFailed       State = "failed"
+)
+
+type Status struct {
+	State       State
+	LastError   string
+	RetryCount  int
+	Since       time.Time
+	LastFrameAt *time.Time
+	NextRetryAt *time.Time
+}
+
+// Transition moves the status to state, keeping Since untouched when the
+// state does not change.
+func (s *Status) Transition(state State, err error) {
+	if s.State != state {
+		s.State = state
+		s.Since = time.Now()
+	}
+	if err != nil {
+		s.LastError = err.Error()
+	}
+}
+
 type CameraDetails struct {
-	ID    string
-	Name  string
-	Infos Infos
+	ID       string
+	Name     string
+	Infos    Infos
+	Status   Status
+	Settings Settings
 }
 
 type Infos struct {
@@ -25,3 +88,103 @@ type Infos struct {
 	Height   int
 	FPS      float64
 }
+
+type SourceType string
+
+const (
+	SourceDevice SourceType = "device"
+	SourceStream SourceType = "stream"
+	// SourceImages plays the image files of a directory in a loop
+	SourceImages SourceType = "images"
+	// SourceVirtual generates its frames, no device needed: a moving test
+	// pattern, a clock or a video file played in a loop
+	SourceVirtual SourceType = "virtual"
+	// SourceFile replays a recorded video at its own frame rate, in a loop
+	// or once, so detection can be evaluated on known footage
+	SourceFile SourceType = "file"
+)
+
+// Settings are the per camera values taking precedence over the global
+// camera configuration, zero values keep the global ones.
+type Settings struct {
+	Width         int    `json:"width,omitempty"`
+	Height        int    `json:"height,omitempty"`
+	FPS           int    `json:"fps,omitempty"`
+	Codec         string `json:"codec,omitempty"`
+	JPEGQuality   int    `json:"jpeg_quality,omitempty"`
+	MinArea       int    `json:"min_area,omitempty"`
+	RecordingMode string `json:"recording_mode,omitempty"`
+	Overlay       *bool  `json:"overlay,omitempty"`
+	// Loop only applies to file sources
+	Loop   *bool            `json:"loop,omitempty"`
+	Motion *motion.Settings `json:"motion,omitempty"`
+}
+
+// Definition is a camera of the registry, either discovered or added through
+// the API. The ID is assigned once, so it survives device renumbering and URL
+// edits. Disabled definitions are kept so removed devices are not picked up
+// again by the next scan.
+type Definition struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Type      SourceType `json:"type"`
+	Source    string     `json:"source"`
+	Settings  Settings   `json:"settings"`
+	Enabled   bool       `json:"enabled"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Update holds the fields to change on a Definition, nil fields are kept.
+type Update struct {
+	Name     *string
+	Source   *string
+	Settings *Settings
+	Enabled  *bool
+}
+
+// DeviceID returns what the frame source opens, resolving device paths to their current index.
+func (d Definition) DeviceID() (interface{}, error) {
+	switch d.Type {
+	case SourceDevice:
+		if index, err := strconv.Atoi(d.Source); err == nil {
+			if index < 0 {
+				return nil, errors.New("device index must be positive")
+			}
+			return index, nil
+		}
+
+		path, err := filepath.EvalSymlinks(d.Source)
+		if err != nil {
+			return nil, fmt.Errorf("device %s not found", d.Source)
+		}
+		var index int
+		if _, err := fmt.Sscanf(filepath.Base(path), "video%d", &index); err != nil {
+			return nil, fmt.Errorf("%s is not a video device", d.Source)
+		}
+		return index, nil
+	case SourceStream:
+		if d.Source == "" {
+			return nil, errors.New("stream source must be a URL")
+		}
+		return d.Source, nil
+	case SourceImages:
+		if d.Source == "" {
+			return nil, errors.New("images source must be a directory")
+		}
+		return d.Source, nil
+	case SourceVirtual:
+		return d.Source, nil
+	case SourceFile:
+		if d.Source == "" {
+			return nil, errors.New("file source must be a video file")
+		}
+		return d.Source, nil
+	default:
+		return nil, errors.New("unknown camera source type")
+	}
+}
+
+type Repository interface {
+	List(ctx context.Context) ([]Definition, error)
+	Save(ctx context.Context, definition Definition) error
+}
@@ -0,0 +1,129 @@
+package motion
+
+import (
+	"context"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventStart EventType = "motion_start"
+	EventEnd   EventType = "motion_end"
+)
+
+type BoundingBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// Detection is the result of running the detector over a single frame. Score is
+// the fraction of the frame covered by contours larger than the minimum area.
+type Detection struct {
+	Score float64
+	Boxes []BoundingBox
+}
+
+func (d Detection) Motion() bool {
+	return len(d.Boxes) > 0
+}
+
+type Algorithm string
+
+const (
+	AlgorithmMOG2 Algorithm = "mog2"
+	AlgorithmKNN  Algorithm = "knn"
+	// AlgorithmDiff compares each frame with the previous one, cheap but
+	// blind to objects that stop moving
+	AlgorithmDiff Algorithm = "diff"
+)
+
+// Settings tune the detector of a single camera, zero values keep the
+// global ones. VarThreshold is the variance threshold of MOG2 and the squared
+// distance threshold of KNN, Threshold binarizes the foreground mask and
+// MinFrames is the number of consecutive frames with motion required before
+// reporting it.
+type Settings struct {
+	Algorithm     Algorithm `json:"algorithm,omitempty"`
+	History       int       `json:"history,omitempty"`
+	VarThreshold  float64   `json:"var_threshold,omitempty"`
+	DetectShadows *bool     `json:"detect_shadows,omitempty"`
+	Threshold     int       `json:"threshold,omitempty"`
+	KernelSize    int       `json:"kernel_size,omitempty"`
+	MinFrames     int       `json:"min_frames,omitempty"`
+}
+
+type Event struct {
+	ID       string        `json:"id"`
+	CameraID string        `json:"camera_id"`
+	Start    time.Time     `json:"start"`
+	End      *time.Time    `json:"end,omitempty"`
+	Score    float64       `json:"score"`
+	Boxes    []BoundingBox `json:"boxes"`
+}
+
+type Listener func(eventType EventType, event Event)
+
+type Filter struct {
+	CameraID string
+	From     time.Time
+	To       time.Time
+	Page     int
+	PageSize int
+}
+
+type EventPage struct {
+	Items    []Event `json:"items"`
+	Total    int     `json:"total"`
+	Page     int     `json:"page"`
+	PageSize int     `json:"page_size"`
+}
+
+type EventRepository interface {
+	Save(ctx context.Context, event Event) (Event, error)
+	List(ctx context.Context, filter Filter) (EventPage, error)
+}
+
+type ZoneKind string
+
+const (
+	// Only motion inside include zones is counted, the whole frame when a
+	// camera has no active include zone
+	ZoneInclude ZoneKind = "include"
+	// Motion inside exclude zones is ignored, e.g. trees or a street in view
+	ZoneExclude ZoneKind = "exclude"
+)
+
+// Point is a polygon vertex relative to the frame size, from 0 to 1, so
+// zones survive resolution changes.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Zone is a polygon restricting motion detection on a camera.
+type Zone struct {
+	ID        string    `json:"id"`
+	CameraID  string    `json:"camera_id"`
+	Name      string    `json:"name"`
+	Kind      ZoneKind  `json:"kind"`
+	Points    []Point   `json:"points"`
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ZoneUpdate holds the fields to change on a Zone, nil fields are kept.
+type ZoneUpdate struct {
+	Name    *string
+	Kind    *ZoneKind
+	Points  *[]Point
+	Enabled *bool
+}
+
+type ZoneRepository interface {
+	List(ctx context.Context, cameraID string) ([]Zone, error)
+	Save(ctx context.Context, zone Zone) error
+	Delete(ctx context.Context, id string) error
+}
@@ -0,0 +1,143 @@
+package recording
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Mode string
+
+const (
+	ModeAlways Mode = "always"
+	ModeMotion Mode = "motion"
+	ModeOff    Mode = "off"
+)
+
+func ParseMode(mode string) (Mode, error) {
+	switch m := Mode(mode); m {
+	case ModeAlways, ModeMotion, ModeOff:
+		return m, nil
+	case "":
+		return ModeOff, nil
+	default:
+		return "", fmt.Errorf("invalid recording mode %q", mode)
+	}
+}
+
+type Segment struct {
+	ID       string    `json:"id"`
+	CameraID string    `json:"camera_id"`
+	Path     string    `json:"-"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Size     int64     `json:"size"`
+	Motion   bool      `json:"motion"`
+}
+
+type Filter struct {
+	CameraID string
+	From     time.Time
+	To       time.Time
+	// Motion keeps only the segments with (true) or without (false) motion
+	Motion   *bool
+	Page     int
+	PageSize int
+}
+
+type SegmentPage struct {
+	Items    []Segment `json:"items"`
+	Total    int       `json:"total"`
+	Page     int       `json:"page"`
+	PageSize int       `json:"page_size"`
+}
+
+type Repository interface {
+	Save(ctx context.Context, segment Segment) (Segment, error)
+	GetByID(ctx context.Context, id string) (*Segment, error)
+	List(ctx context.Context, filter Filter) (SegmentPage, error)
+	DeleteByPath(ctx context.Context, path string) error
+}
+
+type Options struct {
+	Dir             string
+	MotionOnly      bool
+	PreRoll         time.Duration
+	PostRoll        time.Duration
+	SegmentDuration time.Duration
+	MaxSegmentSize  int64
+	// OnSegment is called every time a segment file is finalized
+	OnSegment func(Segment)
+}
+
+// Milliseconds keep segments rotated or restarted within the same second
+// apart, files named before carry whole seconds only.
+const (
+	segmentTimeLayout       = "20060102T150405.000"
+	legacySegmentTimeLayout = "20060102T150405"
+)
+
+// SegmentFileName builds the file name of a segment from the camera ID and the
+// time its first frame was written, so footage can be browsed by time range.
+// A positive sequence tells apart segments started in the same millisecond.
+func SegmentFileName(cameraID string, start time.Time, sequence int) string {
+	if sequence > 0 {
+		return fmt.Sprintf("%s_%s-%d.avi", cameraID, start.Format(segmentTimeLayout), sequence)
+	}
+	return fmt.Sprintf("%s_%s.avi", cameraID, start.Format(segmentTimeLayout))
+}
+
+// ParseSegmentFileName extracts the camera ID and start time from a file name
+// produced by SegmentFileName.
+func ParseSegmentFileName(name string) (string, time.Time, error) {
+	base := strings.TrimSuffix(filepath.Base(name), filepath.Ext(name))
+	idx := strings.LastIndex(base, "_")
+	if idx <= 0 {
+		return "", time.Time{}, fmt.Errorf("invalid segment file name %q", name)
+	}
+
+	timestamp := base[idx+1:]
+	if i := strings.LastIndex(timestamp, "-"); i > 0 {
+		if _, err := strconv.Atoi(timestamp[i+1:]); err != nil {
+			return "", time.Time{}, fmt.Errorf("invalid segment file name %q: %v", name, err)
+		}
+		timestamp = timestamp[:i]
+	}
+
+	start, err := time.ParseInLocation(segmentTimeLayout, timestamp, time.Local)
+	if err != nil {
+		start, err = time.ParseInLocation(legacySegmentTimeLayout, timestamp, time.Local)
+	}
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("invalid segment file name %q: %v", name, err)
+	}
+	return base[:idx], start, nil
+}
+
+type CameraUsage struct {
+	CameraID string    `json:"camera_id"`
+	Bytes    int64     `json:"bytes"`
+	Segments int       `json:"segments"`
+	Oldest   time.Time `json:"oldest"`
+	Newest   time.Time `json:"newest"`
+}
+
+type StorageUsage struct {
+	Path       string        `json:"path"`
+	TotalBytes uint64        `json:"total_bytes"`
+	FreeBytes  uint64        `json:"free_bytes"`
+	UsedBytes  int64         `json:"used_bytes"`
+	Cameras    []CameraUsage `json:"cameras"`
+}
+
+type Storage interface {
+	Path() string
+	List() ([]Segment, error)
+	Open(segment Segment) (*os.File, error)
+	Delete(segment Segment) error
+	DiskUsage() (total uint64, free uint64, err error)
+}
@@ -0,0 +1,70 @@
+package recording
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSegmentFileNameRoundTrip(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 30, 15, 123_000_000, time.Local)
+
+	name := SegmentFileName("cam-1", start, 0)
+	if name != "cam-1_20240501T123015.123.avi" {
+		t.Fatalf("SegmentFileName = %q", name)
+	}
+
+	cameraID, parsed, err := ParseSegmentFileName("/recordings/cam-1/" + name)
+	if err != nil {
+		t.Fatalf("ParseSegmentFileName: %v", err)
+	}
+	if cameraID != "cam-1" || !parsed.Equal(start) {
+		t.Errorf("parsed %q %v, want cam-1 %v", cameraID, parsed, start)
+	}
+}
+
+func TestSegmentFileNameWithinSameSecond(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 30, 15, 0, time.Local)
+
+	first := SegmentFileName("cam", start, 0)
+	second := SegmentFileName("cam", start.Add(400*time.Millisecond), 0)
+	if first == second {
+		t.Errorf("segments started within the same second share the name %q", first)
+	}
+}
+
+func TestSegmentFileNameSequence(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 30, 15, 123_000_000, time.Local)
+
+	name := SegmentFileName("cam-1", start, 2)
+	if name != "cam-1_20240501T123015.123-2.avi" {
+		t.Fatalf("SegmentFileName = %q", name)
+	}
+
+	// The sequence keeps the real start time
+	cameraID, parsed, err := ParseSegmentFileName(name)
+	if err != nil {
+		t.Fatalf("ParseSegmentFileName: %v", err)
+	}
+	if cameraID != "cam-1" || !parsed.Equal(start) {
+		t.Errorf("parsed %q %v, want cam-1 %v", cameraID, parsed, start)
+	}
+}
+
+func TestParseLegacySegmentFileName(t *testing.T) {
+	cameraID, start, err := ParseSegmentFileName("cam_20240501T123015.avi")
+	if err != nil {
+		t.Fatalf("ParseSegmentFileName: %v", err)
+	}
+	want := time.Date(2024, 5, 1, 12, 30, 15, 0, time.Local)
+	if cameraID != "cam" || !start.Equal(want) {
+		t.Errorf("parsed %q %v, want cam %v", cameraID, start, want)
+	}
+}
+
+func TestParseInvalidSegmentFileName(t *testing.T) {
+	for _, name := range []string{"noseparator.avi", "cam_yesterday.avi", "cam_20240501T123015.123-x.avi"} {
+		if _, _, err := ParseSegmentFileName(name); err == nil {
+			t.Errorf("ParseSegmentFileName(%q) should fail", name)
+		}
+	}
+}
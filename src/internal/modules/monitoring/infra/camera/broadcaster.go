@@ -0,0 +1,117 @@
+package camera
+
+import (
+	"sync"
+
+	"gocv.io/x/gocv"
+)
+
+// frameSubscriber receives its own copy of every published frame through a
+// bounded queue. When the queue is full the oldest frame is dropped, so a slow
+// consumer only loses frames instead of stalling the capture loop.
+type frameSubscriber struct {
+	frames      chan gocv.Mat
+	broadcaster *frameBroadcaster
+}
+
+// Close unsubscribes and releases any frame still queued.
+func (s *frameSubscriber) Close() {
+	s.broadcaster.unsubscribe(s)
+}
+
+type frameBroadcaster struct {
+	mu          sync.Mutex
+	subscribers map[*frameSubscriber]struct{}
+	closed      bool
+}
+
+func newFrameBroadcaster() *frameBroadcaster {
+	return &frameBroadcaster{
+		subscribers: make(map[*frameSubscriber]struct{}),
+	}
+}
+
+func (b *frameBroadcaster) subscribe(size int) *frameSubscriber {
+	if size < 1 {
+		size = 1
+	}
+
+	sub := &frameSubscriber{
+		frames:      make(chan gocv.Mat, size),
+		broadcaster: b,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		close(sub.frames)
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+func (b *frameBroadcaster) unsubscribe(sub *frameSubscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	b.release(sub)
+}
+
+// release must be called with b.mu held, after sub stopped receiving frames.
+func (b *frameBroadcaster) release(sub *frameSubscriber) {
+	close(sub.frames)
+	for img := range sub.frames {
+		img.Close()
+	}
+}
+
+// publish hands a clone of img to every subscriber and closes img.
+func (b *frameBroadcaster) publish(img gocv.Mat) {
+	defer img.Close()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		frame := img.Clone()
+		select {
+		case sub.frames <- frame:
+			continue
+		default:
+		}
+
+		// Queue full, drop the oldest frame to make room for the newest
+		select {
+		case old := <-sub.frames:
+			old.Close()
+		default:
+		}
+
+		select {
+		case sub.frames <- frame:
+		default:
+			frame.Close()
+		}
+	}
+}
+
+func (b *frameBroadcaster) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		b.release(sub)
+	}
+}
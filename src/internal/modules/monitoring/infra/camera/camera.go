@@ -3,139 +3,223 @@ package camera
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"image/jpeg"
+	"io"
+	"math"
 	"monitoring-system/src/config"
 	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	motion_infra "monitoring-system/src/internal/modules/monitoring/infra/motion"
 	"monitoring-system/src/pkg/logger"
+	"sync"
 	"time"
 
 	"gocv.io/x/gocv"
 )
 
+const (
+	// The encoder only cares about the latest frame
+	encoderQueueSize = 1
+	motionQueueSize  = 2
+	// Longest wait for a blocked read before Close gives up waiting, the
+	// source is then released as soon as the read returns
+	closeTimeout = 5 * time.Second
+)
+
 type Camera struct {
-	id         string
-	deviceID   interface{}
-	webcam     *gocv.VideoCapture
-	logger     logger.Logger
-	outputChan chan gocv.Mat
+	id       string
+	deviceID interface{}
+	source   FrameSource
+	logger   logger.Logger
+	frames   *frameBroadcaster
+	// detections runs the motion detector shared by events and recordings
+	detections *motionHub
+	jpegs      *jpegCache
 	details    *camera.CameraDetails
+	lastFrame  time.Time
+	mu         sync.RWMutex
 	ctx        context.Context
 	cancel     context.CancelFunc
 	done       chan struct{}
-	config     *config.CameraConfig
+	// captured is closed once the capture loop stopped reading the source
+	captured  chan struct{}
+	closeOnce sync.Once
+	config    *config.CameraConfig
+	// zonesVersion is bumped on every change so detectors know when to
+	// rebuild their mask
+	zones        []motion.Zone
+	zonesVersion int
 }
 
-func NewCameraService(ctx context.Context, id string, deviceID interface{}, logger logger.Logger, config *config.CameraConfig) camera.CameraService {
-	if deviceID == nil {
-		return nil
-	}
-
-	switch v := deviceID.(type) {
-	case int:
-		if v < 0 {
-			logger.Error("deviceID is not a valid int")
-			return nil
-		}
-	case string:
-		if v == "" {
-			logger.Error("deviceID is not a valid string")
-			return nil
-		}
-	default:
-		logger.Error("deviceID is not of type int or string")
+// NewCameraService expects config to be already resolved for this camera,
+// per camera overrides included.
+func NewCameraService(ctx context.Context, id, name string, source FrameSource, logger logger.Logger, config *config.CameraConfig) camera.CameraService {
+	if source == nil {
 		return nil
 	}
 
 	cameraDetails := &camera.CameraDetails{
 		ID:    id,
-		Name:  fmt.Sprintf("Camera %d", deviceID),
+		Name:  name,
 		Infos: camera.Infos{},
+		Status: camera.Status{
+			State: camera.StateStarting,
+			Since: time.Now(),
+		},
+		Settings: camera.Settings{
+			Width:         config.Width,
+			Height:        config.Height,
+			FPS:           config.FPS,
+			Codec:         config.Codec,
+			JPEGQuality:   config.JPEGQuality,
+			MinArea:       config.MinArea,
+			RecordingMode: config.Recording.Mode,
+			Overlay:       &config.Overlay,
+			Loop:          &config.Loop,
+			Motion: &motion.Settings{
+				Algorithm:     motion.Algorithm(config.Motion.Algorithm),
+				History:       config.Motion.History,
+				VarThreshold:  config.Motion.VarThreshold,
+				DetectShadows: config.Motion.DetectShadows,
+				Threshold:     config.Motion.Threshold,
+				KernelSize:    config.Motion.KernelSize,
+				MinFrames:     config.Motion.MinFrames,
+			},
+		},
 	}
 	ctx, cancel := context.WithCancel(ctx)
-	return &Camera{
-		id:         id,
-		deviceID:   deviceID,
-		logger:     logger,
-		ctx:        ctx,
-		cancel:     cancel,
-		outputChan: make(chan gocv.Mat),
-		details:    cameraDetails,
-		done:       make(chan struct{}),
-		config:     config,
+	cam := &Camera{
+		id:       id,
+		deviceID: id,
+		source:   source,
+		logger:   logger,
+		ctx:      ctx,
+		cancel:   cancel,
+		frames:   newFrameBroadcaster(),
+		jpegs:    newJPEGCache(),
+		details:  cameraDetails,
+		done:     make(chan struct{}),
+		config:   config,
 	}
+	cam.detections = newMotionHub(cam)
+	return cam
 }
 
-func (w *Camera) getInfos() (camera.Infos, error) {
-	width := w.webcam.Get(gocv.VideoCaptureFrameWidth)
-	height := w.webcam.Get(gocv.VideoCaptureFrameHeight)
-	if width == 0 || height == 0 {
-		return camera.Infos{}, fmt.Errorf("unable to get dimensions for device: %d", w.deviceID)
-	}
-
-	fps := w.webcam.Get(gocv.VideoCaptureFPS)
-	if fps == 0 {
-		return camera.Infos{}, fmt.Errorf("unable to get FPS for device: %d", w.deviceID)
-	}
+func (w *Camera) setStatus(state camera.State, retries int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-	return camera.Infos{
-		DeviceID: w.deviceID,
-		Width:    int(width),
-		Height:   int(height),
-		FPS:      fps,
-	}, nil
+	w.details.Status.Transition(state, err)
+	w.details.Status.RetryCount = retries
 }
 
 func (w *Camera) Start() error {
-	// w.logger.Info("Starting webcam", w.deviceID)
-	webcam, err := gocv.OpenVideoCapture(w.deviceID)
+	err := w.start()
 	if err != nil {
-		return err
-	}
-
-	if !webcam.IsOpened() {
-		return fmt.Errorf("error starting webcam device %d", w.deviceID)
+		w.setStatus(camera.StateFailed, 0, err)
 	}
+	return err
+}
 
-	w.webcam = webcam
-	infos, err := w.getInfos()
+func (w *Camera) start() error {
+	infos, err := w.source.Open()
 	if err != nil {
 		return err
 	}
+	w.deviceID = infos.DeviceID
 
 	if infos.FPS <= 0 {
-		return fmt.Errorf("error starting webcam device %d fps: %f", w.deviceID, infos.FPS)
+		return fmt.Errorf("error starting webcam device %v fps: %f", w.deviceID, infos.FPS)
+	}
+	if w.config.Width > 0 && w.config.Height > 0 {
+		infos.Width, infos.Height = w.config.Width, w.config.Height
+	}
+	if w.config.FPS > 0 && float64(w.config.FPS) < infos.FPS {
+		infos.FPS = float64(w.config.FPS)
 	}
 
-	webcam.Set(gocv.VideoCaptureFrameWidth, float64(w.config.Width))
-	webcam.Set(gocv.VideoCaptureFrameHeight, float64(w.config.Height))
-	webcam.Set(gocv.VideoCaptureFPS, float64(w.config.FPS))
-	webcam.Set(gocv.VideoCaptureFOURCC, float64(webcam.ToCodec(w.config.Codec)))
-
+	w.mu.Lock()
+	// Without a configured frame rate the camera runs at the one of its
+	// source, recordings and pre-roll need it
+	if w.config.FPS <= 0 {
+		w.config.FPS = max(1, int(math.Round(infos.FPS)))
+		w.details.Settings.FPS = w.config.FPS
+	}
 	w.details.Infos = infos
+	w.mu.Unlock()
+
+	w.mu.Lock()
+	w.captured = make(chan struct{})
+	w.mu.Unlock()
 
 	go w.capture()
+	go w.encodeFrames()
 
 	w.logger.Info("Camera started", w.deviceID)
 
 	return nil
 }
 
+// Close stops the capture loop and releases the source once the loop no
+// longer reads it, a native handle must not be freed during a read. Done is
+// closed when the source is released.
 func (w *Camera) Close() error {
-	w.logger.Warning("Closing webcam", w.deviceID)
-	w.cancel()
-	close(w.done)
-	return w.webcam.Close()
+	var err error
+	w.closeOnce.Do(func() {
+		w.logger.Warning("Closing webcam %v", w.deviceID)
+		w.mu.Lock()
+		if w.details.Status.State != camera.StateFailed {
+			w.details.Status.Transition(camera.StateStopped, nil)
+		}
+		captured := w.captured
+		w.mu.Unlock()
+
+		w.cancel()
+		w.frames.close()
+
+		if captured != nil {
+			select {
+			case <-captured:
+			case <-time.After(closeTimeout):
+				w.logger.Warning("Webcam %v still reading after %v, releasing it once the read returns", w.deviceID, closeTimeout)
+				go func() {
+					<-captured
+					if err := w.source.Close(); err != nil {
+						w.logger.Error("Error closing webcam %v: %v", w.deviceID, err)
+					}
+				}()
+				close(w.done)
+				return
+			}
+		}
+
+		err = w.source.Close()
+		close(w.done)
+	})
+	return err
 }
 
 func (w *Camera) capture() {
-	defer w.Close()
+	// Close waits for captured, the loop has to be marked as done first
+	defer func() {
+		close(w.captured)
+		w.Close()
+	}()
 
 	maxRetries := 5
 	retries := 0
 
+	var minInterval time.Duration
+	if w.config.FPS > 0 {
+		minInterval = time.Second / time.Duration(w.config.FPS)
+	}
+	var lastPublished time.Time
+
 	for {
 		select {
 		case <-w.done:
@@ -147,126 +231,315 @@ func (w *Camera) capture() {
 		default:
 			img := gocv.NewMat()
 
-			if ok := w.webcam.Read(&img); !ok || img.Empty() {
+			if err := w.source.Read(&img); err != nil {
 				img.Close()
+				if errors.Is(err, io.EOF) {
+					w.logger.Info("Camera %s reached the end of %v", w.id, w.deviceID)
+					w.setStatus(camera.StateStopped, 0, nil)
+					return
+				}
 				retries++
 				if retries >= maxRetries {
-					w.logger.Warning("Unable to read from device %d after %d retries\n", w.deviceID, retries)
+					w.logger.Warning("Unable to read from device %v after %d retries", w.deviceID, retries)
+					w.setStatus(camera.StateFailed, retries, fmt.Errorf("unable to read from device after %d retries", retries))
 					return
 				}
+				w.setStatus(camera.StateDegraded, retries, err)
 				// w.logger.Warning("Retrying capture for device %d", w.deviceID)
-				time.Sleep(1 * time.Second)
+				select {
+				case <-w.ctx.Done():
+				case <-time.After(1 * time.Second):
+				}
 				continue
 			}
 			retries = 0
 
-			font := gocv.FontHersheyPlain
-			scale := 1.5
-			color := color.RGBA{R: 255, G: 255, B: 255, A: 0}
-			thickness := 2
-			position := image.Point{X: 10, Y: w.details.Infos.Height - 10}
+			now := time.Now()
+			w.mu.Lock()
+			w.details.Status.Transition(camera.StateStreaming, nil)
+			w.details.Status.RetryCount = 0
+			w.lastFrame = now
+			w.mu.Unlock()
 
-			timestamp := time.Now().Format("2006-01-02 15:04:05")
-			gocv.PutText(&img, timestamp, position, font, scale, color, thickness)
+			// Keep some slack so jitter does not halve the frame rate
+			if now.Sub(lastPublished) < minInterval*9/10 {
+				img.Close()
+				continue
+			}
+			lastPublished = now
 
-			select {
-			case <-w.done:
-				w.logger.Warning("Capture stopped by done signal")
-				return
-			case <-w.ctx.Done():
-				w.logger.Warning("Capture stopped by context cancellation")
-				return
-			case w.outputChan <- img:
-				// Image sent successfully
+			if w.config.Width > 0 && w.config.Height > 0 && (img.Cols() != w.config.Width || img.Rows() != w.config.Height) {
+				resized := gocv.NewMat()
+				gocv.Resize(img, &resized, image.Point{X: w.config.Width, Y: w.config.Height}, 0, 0, gocv.InterpolationArea)
+				img.Close()
+				img = resized
 			}
+
+			if w.config.Overlay {
+				font := gocv.FontHersheyPlain
+				scale := 1.5
+				color := color.RGBA{R: 255, G: 255, B: 255, A: 0}
+				thickness := 2
+				position := image.Point{X: 10, Y: img.Rows() - 10}
+
+				timestamp := now.Format("2006-01-02 15:04:05")
+				gocv.PutText(&img, timestamp, position, font, scale, color, thickness)
+			}
+
+			w.frames.publish(img)
 		}
 	}
 }
 
+func (w *Camera) Subscribe() camera.FrameSubscription {
+	return newFrameSubscription(w)
+}
+
 func (w *Camera) Capture() ([]byte, error) {
+	sub := w.Subscribe()
+	defer sub.Close()
+	return sub.Capture()
+}
+
+// Snapshot returns the next frame, served from the shared JPEG cache unless it
+// has to be resized or encoded at another quality.
+func (w *Camera) Snapshot(ctx context.Context, opts camera.SnapshotOptions) ([]byte, error) {
+	if opts.Width <= 0 && opts.Height <= 0 && (opts.Quality <= 0 || opts.Quality == w.config.JPEGQuality) {
+		sub := newFrameSubscription(w)
+		defer sub.Close()
+		return sub.capture(ctx)
+	}
+
+	sub := w.frames.subscribe(1)
+	defer sub.Close()
+
+	var img gocv.Mat
 	select {
 	case <-w.done:
-		w.logger.Info("Camera done signal received, stopping capture", w.deviceID)
-		return nil, nil
-	case <-w.ctx.Done():
-		w.logger.Info("Context done, stopping capture", w.deviceID)
-		return nil, nil
-	case img := <-w.outputChan:
-		defer img.Close()
-		image, err := img.ToImage()
-		if err != nil {
-			w.logger.Error("Error to get image.Image from gocv.Mat", err)
-			return nil, err
+		return nil, ErrCameraClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case frame, ok := <-sub.frames:
+		if !ok {
+			return nil, ErrCameraClosed
 		}
+		img = frame
+	}
+	defer img.Close()
 
-		buffer := new(bytes.Buffer)
-		if err := jpeg.Encode(buffer, image, &jpeg.Options{Quality: 75}); err != nil {
-			w.logger.Error("Error encoding image", err)
-			return nil, err
-		}
+	quality := opts.Quality
+	if quality <= 0 {
+		quality = w.config.JPEGQuality
+	}
+
+	width, height := opts.Width, opts.Height
+	if width > 0 && height <= 0 {
+		height = img.Rows() * width / img.Cols()
+	} else if height > 0 && width <= 0 {
+		width = img.Cols() * height / img.Rows()
+	}
+	if width <= 0 || height <= 0 {
+		return w.encode(img, quality)
+	}
 
-		return buffer.Bytes(), nil
+	resized := gocv.NewMat()
+	defer resized.Close()
+	if err := gocv.Resize(img, &resized, image.Point{X: width, Y: height}, 0, 0, gocv.InterpolationArea); err != nil {
+		return nil, err
+	}
+	return w.encode(resized, quality)
+}
+
+// encodeFrames keeps the JPEG cache up to date, encoding each captured frame
+// once no matter how many viewers are watching.
+func (w *Camera) encodeFrames() {
+	sub := w.frames.subscribe(encoderQueueSize)
+	defer sub.Close()
+
+	quality := w.config.JPEGQuality
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case img, ok := <-sub.frames:
+			if !ok {
+				return
+			}
+			if w.jpegs.active() {
+				if frame, err := w.encode(img, quality); err == nil {
+					w.jpegs.store(frame)
+				}
+			}
+			img.Close()
+		}
 	}
 }
 
-func (w *Camera) RecordVideo(ctx context.Context, filename string, motionOnly bool) error {
-	writer, err := gocv.VideoWriterFile(filename, w.config.Codec, float64(w.config.FPS), w.config.Width, w.config.Height, w.config.MotionDetection)
+func (w *Camera) encode(img gocv.Mat, quality int) ([]byte, error) {
+	image, err := img.ToImage()
 	if err != nil {
-		return err
+		w.logger.Error("Error to get image.Image from gocv.Mat %v", err)
+		return nil, err
+	}
+
+	buffer := new(bytes.Buffer)
+	if err := jpeg.Encode(buffer, image, &jpeg.Options{Quality: quality}); err != nil {
+		w.logger.Error("Error encoding image %v", err)
+		return nil, err
 	}
+
+	return buffer.Bytes(), nil
+}
+
+func (w *Camera) RecordVideo(ctx context.Context, opts recording.Options) error {
+	// A second worth of frames, recording should not lose frames on short hiccups
+	sub := w.frames.subscribe(w.config.FPS)
+	defer sub.Close()
+
+	writer := newSegmentWriter(w.id, w.config.Codec, float64(w.config.FPS), opts, w.logger)
 	defer writer.Close()
 
-	imgDelta := gocv.NewMat()
-	defer imgDelta.Close()
+	if !opts.MotionOnly {
+		// Continuous segments are flagged from the detector of motion events,
+		// it is not started only for that
+		var detections <-chan motion.Detection
+		if w.config.MotionDetection {
+			listener, err := w.detections.subscribe()
+			if err != nil {
+				return err
+			}
+			defer listener.Close()
+			detections = listener.detections
+		}
+
+		for {
+			select {
+			case <-w.done:
+				w.logger.Info("Recording done for device %v", w.deviceID)
+				return nil
+			case <-ctx.Done():
+				w.logger.Warning("Recording stopped by context cancellation")
+				return nil
+			case detection := <-detections:
+				if detection.Motion() {
+					writer.markMotion()
+				}
+			case img, ok := <-sub.frames:
+				if !ok {
+					return nil
+				}
+				err := writer.Write(img)
+				img.Close()
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
 
-	imgThresh := gocv.NewMat()
-	defer imgThresh.Close()
+	listener, err := w.detections.subscribe()
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
 
-	mog2 := gocv.NewBackgroundSubtractorMOG2()
-	defer mog2.Close()
+	clip := newMotionClip(writer, opts.PreRoll, opts.PostRoll, w.config.FPS)
+	defer clip.Close()
 
 	for {
 		select {
 		case <-w.done:
-			w.logger.Info("Recording done for device", w.deviceID)
+			w.logger.Info("Recording done for device %v", w.deviceID)
 			return nil
 		case <-ctx.Done():
 			w.logger.Warning("Recording stopped by context cancellation")
 			return nil
-		case img := <-w.outputChan:
-			if motionOnly {
-				mog2.Apply(img, &imgDelta)
+		case detection := <-listener.detections:
+			if err := clip.detect(detection); err != nil {
+				return err
+			}
+		case img, ok := <-sub.frames:
+			if !ok {
+				return nil
+			}
+			if err := clip.frame(img); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// DetectMotion reports the detections of the camera detector, shared with
+// motion recordings.
+func (w *Camera) DetectMotion(ctx context.Context, onDetection func(motion.Detection)) error {
+	listener, err := w.detections.subscribe()
+	if err != nil {
+		return err
+	}
+	defer listener.Close()
+
+	for {
+		select {
+		case <-w.done:
+			w.logger.Info("Motion detection done for device %v", w.deviceID)
+			return nil
+		case <-ctx.Done():
+			w.logger.Warning("Motion detection stopped by context cancellation")
+			return nil
+		case detection := <-listener.detections:
+			onDetection(detection)
+		}
+	}
+}
+
+func (w *Camera) SetMotionZones(zones []motion.Zone) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 
-				gocv.Threshold(imgDelta, &imgThresh, 25, 255, gocv.ThresholdBinary)
+	w.zones = append([]motion.Zone(nil), zones...)
+	w.zonesVersion++
+}
 
-				kernel := gocv.GetStructuringElement(gocv.MorphRect, image.Pt(3, 3))
-				gocv.Dilate(imgThresh, &imgThresh, kernel)
-				kernel.Close()
+func (w *Camera) motionZones() ([]motion.Zone, int) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.zones, w.zonesVersion
+}
 
-				contours := gocv.FindContours(imgThresh, gocv.RetrievalExternal, gocv.ChainApproxSimple)
+// zonedDetector keeps a detector in sync with the motion zones of its camera.
+type zonedDetector struct {
+	*motion_infra.Detector
+	camera  *Camera
+	version int
+}
 
-				for i := 0; i < contours.Size(); i++ {
-					area := gocv.ContourArea(contours.At(i))
-					if area < float64(w.config.MinArea) {
-						continue
-					}
-					err := writer.Write(img)
-					if err != nil {
-						w.logger.Error("Error while writing frame")
-					}
-				}
-			} else {
-				err := writer.Write(img)
-				if err != nil {
-					w.logger.Error("Error while writing frame")
-				}
-			}
-		}
+func (w *Camera) newDetector() (*zonedDetector, error) {
+	detector, err := motion_infra.NewDetector(w.config.MinArea, w.config.Motion)
+	if err != nil {
+		return nil, err
+	}
+	return &zonedDetector{Detector: detector, camera: w, version: -1}, nil
+}
+
+func (d *zonedDetector) Detect(img gocv.Mat) motion.Detection {
+	if zones, version := d.camera.motionZones(); version != d.version {
+		d.Detector.SetZones(zones)
+		d.version = version
 	}
+	return d.Detector.Detect(img)
 }
 
 func (w *Camera) GetDetails() camera.CameraDetails {
-	return *w.details
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	details := *w.details
+	if !w.lastFrame.IsZero() {
+		lastFrame := w.lastFrame
+		details.Status.LastFrameAt = &lastFrame
+	}
+	return details
 }
 
 func (w *Camera) Done() <-chan struct{} {
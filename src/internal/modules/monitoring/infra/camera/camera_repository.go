@@ -0,0 +1,82 @@
+package camera
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/pkg/logger"
+	"time"
+)
+
+type cameraRepository struct {
+	sqlDB  *sql.DB
+	logger logger.Logger
+}
+
+func NewCameraRepository(ctx context.Context, db *sql.DB, logger logger.Logger) (camera.Repository, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS cameras (
+			id         VARCHAR(255) PRIMARY KEY,
+			name       VARCHAR(255) NOT NULL,
+			type       VARCHAR(32) NOT NULL,
+			source     TEXT NOT NULL,
+			settings   TEXT NOT NULL DEFAULT '{}',
+			enabled    BOOLEAN NOT NULL DEFAULT 1,
+			created_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		logger.Error("Error creating cameras table: %v", err)
+		return nil, err
+	}
+
+	return &cameraRepository{sqlDB: db, logger: logger}, nil
+}
+
+func (r *cameraRepository) List(ctx context.Context) ([]camera.Definition, error) {
+	rows, err := r.sqlDB.QueryContext(ctx, "SELECT id, name, type, source, settings, enabled, created_at FROM cameras ORDER BY created_at")
+	if err != nil {
+		r.logger.Error("Error querying cameras: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var definitions []camera.Definition
+	for rows.Next() {
+		var definition camera.Definition
+		var settings string
+		var createdAt int64
+
+		if err := rows.Scan(&definition.ID, &definition.Name, &definition.Type, &definition.Source, &settings, &definition.Enabled, &createdAt); err != nil {
+			r.logger.Error("Error scanning camera: %v", err)
+			return nil, err
+		}
+		definition.CreatedAt = time.UnixMilli(createdAt)
+		if err := json.Unmarshal([]byte(settings), &definition.Settings); err != nil {
+			r.logger.Error("Error decoding camera %s settings: %v", definition.ID, err)
+		}
+
+		definitions = append(definitions, definition)
+	}
+
+	return definitions, rows.Err()
+}
+
+func (r *cameraRepository) Save(ctx context.Context, definition camera.Definition) error {
+	settings, err := json.Marshal(definition.Settings)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.sqlDB.ExecContext(ctx, `
+		INSERT INTO cameras (id, name, type, source, settings, enabled, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = excluded.name, type = excluded.type, source = excluded.source, settings = excluded.settings, enabled = excluded.enabled
+	`, definition.ID, definition.Name, definition.Type, definition.Source, string(settings), definition.Enabled, definition.CreatedAt.UnixMilli())
+	if err != nil {
+		r.logger.Error("Error saving camera %s: %v", definition.ID, err)
+		return err
+	}
+	return nil
+}
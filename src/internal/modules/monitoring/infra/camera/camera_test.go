@@ -0,0 +1,110 @@
+package camera
+
+import (
+	"context"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/pkg/logger"
+	"sync"
+	"testing"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+// blockingSource blocks every read until released, like a device waiting
+// for its next frame, and records whether it was closed during a read.
+type blockingSource struct {
+	reading          chan struct{}
+	release          chan struct{}
+	readingOnce      sync.Once
+	mu               sync.Mutex
+	inRead           bool
+	closed           bool
+	closedDuringRead bool
+}
+
+func newBlockingSource() *blockingSource {
+	return &blockingSource{reading: make(chan struct{}), release: make(chan struct{})}
+}
+
+func (s *blockingSource) Open() (camera.Infos, error) {
+	return camera.Infos{DeviceID: "blocking", Width: 64, Height: 48, FPS: 10}, nil
+}
+
+func (s *blockingSource) Read(img *gocv.Mat) error {
+	s.mu.Lock()
+	s.inRead = true
+	s.mu.Unlock()
+	s.readingOnce.Do(func() { close(s.reading) })
+
+	<-s.release
+
+	s.mu.Lock()
+	s.inRead = false
+	s.mu.Unlock()
+	return ErrFrameUnavailable
+}
+
+func (s *blockingSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.closedDuringRead = s.inRead
+	return nil
+}
+
+func (s *blockingSource) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func newTestLogger(t *testing.T) logger.Logger {
+	t.Helper()
+	log, err := logger.NewLogger("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+func TestCloseWaitsForPendingRead(t *testing.T) {
+	source := newBlockingSource()
+	cam := NewCameraService(context.Background(), "cam", "Camera", source, newTestLogger(t), &config.CameraConfig{FPS: 10})
+	if err := cam.Start(); err != nil {
+		t.Fatal(err)
+	}
+	<-source.reading
+
+	closed := make(chan error, 1)
+	go func() { closed <- cam.Close() }()
+
+	select {
+	case <-closed:
+		t.Fatal("Close returned while a read was pending")
+	case <-time.After(200 * time.Millisecond):
+	}
+	if source.isClosed() {
+		t.Fatal("source closed while a read was pending")
+	}
+
+	close(source.release)
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close did not return once the read completed")
+	}
+
+	if !source.isClosed() || source.closedDuringRead {
+		t.Errorf("source closed = %v, during a read = %v", source.isClosed(), source.closedDuringRead)
+	}
+	select {
+	case <-cam.Done():
+	default:
+		t.Error("Done not closed after Close")
+	}
+}
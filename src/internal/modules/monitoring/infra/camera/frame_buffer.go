@@ -0,0 +1,62 @@
+package camera
+
+import (
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+type bufferedFrame struct {
+	img gocv.Mat
+	at  time.Time
+}
+
+// frameBuffer is a ring buffer holding the last frames captured within maxAge.
+// It owns the Mats pushed into it and closes them when they are evicted.
+type frameBuffer struct {
+	frames    []bufferedFrame
+	maxAge    time.Duration
+	maxFrames int
+}
+
+func newFrameBuffer(maxAge time.Duration, fps int) *frameBuffer {
+	maxFrames := int(maxAge.Seconds()*float64(fps)) + 1
+	return &frameBuffer{
+		frames:    make([]bufferedFrame, 0, maxFrames),
+		maxAge:    maxAge,
+		maxFrames: maxFrames,
+	}
+}
+
+func (b *frameBuffer) Push(img gocv.Mat) {
+	now := time.Now()
+	b.frames = append(b.frames, bufferedFrame{img: img, at: now})
+
+	drop := 0
+	for drop < len(b.frames) && (len(b.frames)-drop > b.maxFrames || now.Sub(b.frames[drop].at) > b.maxAge) {
+		b.frames[drop].img.Close()
+		drop++
+	}
+	if drop > 0 {
+		b.frames = append(b.frames[:0], b.frames[drop:]...)
+	}
+}
+
+// Drain calls fn with every buffered frame, oldest first, and empties the buffer.
+func (b *frameBuffer) Drain(fn func(img gocv.Mat) error) error {
+	defer b.Close()
+
+	for _, frame := range b.frames {
+		if err := fn(frame.img); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *frameBuffer) Close() {
+	for _, frame := range b.frames {
+		frame.img.Close()
+	}
+	b.frames = b.frames[:0]
+}
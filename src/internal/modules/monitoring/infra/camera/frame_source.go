@@ -0,0 +1,139 @@
+package camera
+
+import (
+	"errors"
+	"fmt"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+const DEFAULT_SOURCE_FPS = 15
+
+var ErrFrameUnavailable = errors.New("unable to read frame")
+
+// FrameSource produces the raw frames of a camera. The camera takes care of
+// resizing, throttling, overlays and sharing the frames with its consumers.
+type FrameSource interface {
+	// Open starts the source and returns the size and frame rate it delivers.
+	Open() (camera.Infos, error)
+	// Read blocks until the next frame is due and stores it in img.
+	Read(img *gocv.Mat) error
+	Close() error
+}
+
+// NewFrameSource returns the source matching the type of the definition,
+// config must be already resolved for this camera.
+func NewFrameSource(definition camera.Definition, config *config.CameraConfig) (FrameSource, error) {
+	deviceID, err := definition.DeviceID()
+	if err != nil {
+		return nil, err
+	}
+
+	switch definition.Type {
+	case camera.SourceDevice, camera.SourceStream:
+		return newOpenCVSource(deviceID, config), nil
+	case camera.SourceImages:
+		return newImageSource(definition.Source, config), nil
+	case camera.SourceVirtual:
+		if IsPattern(definition.Source) {
+			return newPatternSource(definition.Source, config)
+		}
+		return newVideoSource(definition.Source, true, config), nil
+	case camera.SourceFile:
+		return newVideoSource(definition.Source, config.Loop, config), nil
+	default:
+		return nil, fmt.Errorf("unsupported camera source type %s", definition.Type)
+	}
+}
+
+// openCVSource reads local devices and network streams through OpenCV.
+type openCVSource struct {
+	deviceID interface{}
+	config   *config.CameraConfig
+	webcam   *gocv.VideoCapture
+}
+
+func newOpenCVSource(deviceID interface{}, config *config.CameraConfig) *openCVSource {
+	return &openCVSource{deviceID: deviceID, config: config}
+}
+
+func (s *openCVSource) Open() (camera.Infos, error) {
+	webcam, err := gocv.OpenVideoCapture(s.deviceID)
+	if err != nil {
+		return camera.Infos{}, err
+	}
+	s.webcam = webcam
+
+	if !webcam.IsOpened() {
+		return camera.Infos{}, fmt.Errorf("error starting webcam device %v", s.deviceID)
+	}
+
+	webcam.Set(gocv.VideoCaptureFrameWidth, float64(s.config.Width))
+	webcam.Set(gocv.VideoCaptureFrameHeight, float64(s.config.Height))
+	webcam.Set(gocv.VideoCaptureFPS, float64(s.config.FPS))
+	webcam.Set(gocv.VideoCaptureFOURCC, float64(webcam.ToCodec(s.config.Codec)))
+
+	// Streams ignore the properties above, they are resized and throttled by the camera
+	width := webcam.Get(gocv.VideoCaptureFrameWidth)
+	height := webcam.Get(gocv.VideoCaptureFrameHeight)
+	if width == 0 || height == 0 {
+		return camera.Infos{}, fmt.Errorf("unable to get dimensions for device: %v", s.deviceID)
+	}
+
+	fps := webcam.Get(gocv.VideoCaptureFPS)
+	if fps == 0 {
+		return camera.Infos{}, fmt.Errorf("unable to get FPS for device: %v", s.deviceID)
+	}
+
+	return camera.Infos{
+		DeviceID: s.deviceID,
+		Width:    int(width),
+		Height:   int(height),
+		FPS:      fps,
+	}, nil
+}
+
+func (s *openCVSource) Read(img *gocv.Mat) error {
+	if ok := s.webcam.Read(img); !ok || img.Empty() {
+		return ErrFrameUnavailable
+	}
+	return nil
+}
+
+func (s *openCVSource) Close() error {
+	if s.webcam != nil {
+		return s.webcam.Close()
+	}
+	return nil
+}
+
+// sourceFPS is the frame rate of the sources generating their own frames.
+func sourceFPS(config *config.CameraConfig) int {
+	if config.FPS > 0 {
+		return config.FPS
+	}
+	return DEFAULT_SOURCE_FPS
+}
+
+// pacer spaces the frames of generated sources, without drifting when a
+// frame takes longer than expected.
+type pacer struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newPacer(fps float64) *pacer {
+	return &pacer{interval: time.Duration(float64(time.Second) / fps)}
+}
+
+func (p *pacer) wait() {
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	time.Sleep(p.next.Sub(now))
+	p.next = p.next.Add(p.interval)
+}
@@ -0,0 +1,101 @@
+package camera
+
+import (
+	"fmt"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gocv.io/x/gocv"
+)
+
+var imageExtensions = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".bmp":  true,
+}
+
+// imageSource plays the images of a directory in name order, looping over
+// them. The directory is listed again on every loop so images can be added
+// while the camera runs.
+type imageSource struct {
+	dir    string
+	fps    int
+	pacer  *pacer
+	files  []string
+	cursor int
+}
+
+func newImageSource(dir string, config *config.CameraConfig) *imageSource {
+	fps := sourceFPS(config)
+	return &imageSource{dir: dir, fps: fps, pacer: newPacer(float64(fps))}
+}
+
+func (s *imageSource) list() error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+
+	files := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && imageExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+			files = append(files, filepath.Join(s.dir, entry.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no images found in %s", s.dir)
+	}
+
+	sort.Strings(files)
+	s.files = files
+	s.cursor = 0
+	return nil
+}
+
+func (s *imageSource) Open() (camera.Infos, error) {
+	if err := s.list(); err != nil {
+		return camera.Infos{}, err
+	}
+
+	img := gocv.IMRead(s.files[0], gocv.IMReadColor)
+	defer img.Close()
+	if img.Empty() {
+		return camera.Infos{}, fmt.Errorf("unable to read image %s", s.files[0])
+	}
+
+	return camera.Infos{
+		DeviceID: s.dir,
+		Width:    img.Cols(),
+		Height:   img.Rows(),
+		FPS:      float64(s.fps),
+	}, nil
+}
+
+func (s *imageSource) Read(img *gocv.Mat) error {
+	if s.cursor >= len(s.files) {
+		if err := s.list(); err != nil {
+			return err
+		}
+	}
+	file := s.files[s.cursor]
+	s.cursor++
+
+	s.pacer.wait()
+
+	frame := gocv.IMRead(file, gocv.IMReadColor)
+	defer frame.Close()
+	if frame.Empty() {
+		return fmt.Errorf("unable to read image %s", file)
+	}
+	frame.CopyTo(img)
+	return nil
+}
+
+func (s *imageSource) Close() error {
+	return nil
+}
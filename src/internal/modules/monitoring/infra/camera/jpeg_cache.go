@@ -0,0 +1,63 @@
+package camera
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// jpegCache holds the latest encoded frame of a camera so it is encoded once
+// and shared by every live viewer. Frames are only encoded while someone is
+// watching, tracked by demand.
+type jpegCache struct {
+	mu      sync.Mutex
+	frame   []byte
+	seq     uint64
+	updated chan struct{}
+	demand  atomic.Int32
+}
+
+func newJPEGCache() *jpegCache {
+	return &jpegCache{
+		updated: make(chan struct{}),
+	}
+}
+
+func (c *jpegCache) store(frame []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.frame = frame
+	c.seq++
+	close(c.updated)
+	c.updated = make(chan struct{})
+}
+
+// next returns the cached frame if it is newer than after, otherwise a channel
+// closed when the next frame is stored.
+func (c *jpegCache) next(after uint64) ([]byte, uint64, <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.seq > after {
+		return c.frame, c.seq, nil
+	}
+	return nil, after, c.updated
+}
+
+func (c *jpegCache) current() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.seq
+}
+
+func (c *jpegCache) acquire() {
+	c.demand.Add(1)
+}
+
+func (c *jpegCache) release() {
+	c.demand.Add(-1)
+}
+
+func (c *jpegCache) active() bool {
+	return c.demand.Load() > 0
+}
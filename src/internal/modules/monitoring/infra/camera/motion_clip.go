@@ -0,0 +1,61 @@
+package camera
+
+import (
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+// motionClip records motion events, from PreRoll before the first detection
+// to PostRoll after the last one. Each event gets its own segment, so the
+// indexed start and end times only cover footage.
+type motionClip struct {
+	writer     *segmentWriter
+	preRoll    *frameBuffer
+	postRoll   time.Duration
+	lastMotion time.Time
+}
+
+func newMotionClip(writer *segmentWriter, preRoll, postRoll time.Duration, fps int) *motionClip {
+	return &motionClip{
+		writer:   writer,
+		preRoll:  newFrameBuffer(preRoll, fps),
+		postRoll: postRoll,
+	}
+}
+
+func (c *motionClip) detect(detection motion.Detection) error {
+	if !detection.Motion() {
+		return nil
+	}
+	if err := c.preRoll.Drain(c.writer.Write); err != nil {
+		return err
+	}
+	c.writer.markMotion()
+	c.lastMotion = time.Now()
+	return nil
+}
+
+// frame takes ownership of img, writing it while an event is recorded and
+// keeping it for the pre-roll otherwise.
+func (c *motionClip) frame(img gocv.Mat) error {
+	if !c.lastMotion.IsZero() && time.Since(c.lastMotion) > c.postRoll {
+		c.lastMotion = time.Time{}
+		if err := c.writer.Close(); err != nil {
+			c.writer.logger.Error("Error closing segment %s: %v", c.writer.segment.Path, err)
+		}
+	}
+	if c.lastMotion.IsZero() {
+		c.preRoll.Push(img)
+		return nil
+	}
+
+	err := c.writer.Write(img)
+	img.Close()
+	return err
+}
+
+func (c *motionClip) Close() {
+	c.preRoll.Close()
+}
@@ -0,0 +1,62 @@
+package camera
+
+import (
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	"testing"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+func TestMotionClipSplitsEvents(t *testing.T) {
+	var segments []recording.Segment
+	opts := recording.Options{
+		Dir:       t.TempDir(),
+		OnSegment: func(segment recording.Segment) { segments = append(segments, segment) },
+	}
+	writer := newSegmentWriter("cam", "MJPG", 10, opts, newTestLogger(t))
+	clip := newMotionClip(writer, 100*time.Millisecond, 50*time.Millisecond, 10)
+	defer clip.Close()
+
+	frame := func() {
+		t.Helper()
+		if err := clip.frame(gocv.NewMatWithSize(48, 64, gocv.MatTypeCV8UC3)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	event := func() {
+		t.Helper()
+		frame()
+		if err := clip.detect(motion.Detection{Boxes: []motion.BoundingBox{{}}}); err != nil {
+			t.Fatal(err)
+		}
+		frame()
+		frame()
+	}
+
+	event()
+	// Longer than the post-roll, the first event is over
+	time.Sleep(100 * time.Millisecond)
+	frame()
+	if len(segments) != 1 {
+		t.Fatalf("%d segments once the post-roll ran out, want 1", len(segments))
+	}
+
+	event()
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(segments) != 2 {
+		t.Fatalf("%d segments for two events, want 2", len(segments))
+	}
+	for _, segment := range segments {
+		if !segment.Motion || segment.Size == 0 {
+			t.Errorf("segment = %+v, want a non empty motion segment", segment)
+		}
+	}
+	if segments[0].Path == segments[1].Path || !segments[0].End.Before(segments[1].Start) {
+		t.Errorf("events share a segment: %+v", segments)
+	}
+}
@@ -0,0 +1,119 @@
+package camera
+
+import (
+	"context"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"sync"
+
+	"gocv.io/x/gocv"
+)
+
+// motionListener receives the detections of a camera through a bounded queue,
+// dropping the oldest one when the consumer falls behind.
+type motionListener struct {
+	detections chan motion.Detection
+	hub        *motionHub
+}
+
+// Close stops receiving detections.
+func (l *motionListener) Close() {
+	l.hub.unsubscribe(l)
+}
+
+// motionHub runs the single detector of a camera and shares its detections
+// between motion events and motion recordings, so they always agree and the
+// background model is computed once per frame. The detector only runs while
+// someone listens.
+type motionHub struct {
+	camera    *Camera
+	mu        sync.Mutex
+	listeners map[*motionListener]struct{}
+	cancel    context.CancelFunc
+}
+
+func newMotionHub(camera *Camera) *motionHub {
+	return &motionHub{
+		camera:    camera,
+		listeners: make(map[*motionListener]struct{}),
+	}
+}
+
+func (h *motionHub) subscribe() (*motionListener, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cancel == nil {
+		detector, err := h.camera.newDetector()
+		if err != nil {
+			return nil, err
+		}
+		ctx, cancel := context.WithCancel(h.camera.ctx)
+		h.cancel = cancel
+		go h.run(ctx, detector, h.camera.frames.subscribe(motionQueueSize))
+	}
+
+	listener := &motionListener{
+		detections: make(chan motion.Detection, motionQueueSize),
+		hub:        h,
+	}
+	h.listeners[listener] = struct{}{}
+	return listener, nil
+}
+
+func (h *motionHub) unsubscribe(listener *motionListener) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.listeners, listener)
+	if len(h.listeners) == 0 && h.cancel != nil {
+		h.cancel()
+		h.cancel = nil
+	}
+}
+
+func (h *motionHub) run(ctx context.Context, detector *zonedDetector, sub *frameSubscriber) {
+	defer detector.Close()
+	defer sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case img, ok := <-sub.frames:
+			if !ok {
+				return
+			}
+			detection := h.detect(detector, img)
+			h.publish(detection)
+		}
+	}
+}
+
+func (h *motionHub) detect(detector *zonedDetector, img gocv.Mat) motion.Detection {
+	defer img.Close()
+	return detector.Detect(img)
+}
+
+func (h *motionHub) publish(detection motion.Detection) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for listener := range h.listeners {
+		select {
+		case listener.detections <- detection:
+			continue
+		default:
+		}
+
+		// Queue full, drop the oldest detection to make room for the newest
+		select {
+		case <-listener.detections:
+		default:
+		}
+
+		select {
+		case listener.detections <- detection:
+		default:
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package camera
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"sync"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+const (
+	PATTERN_BARS           = "bars"
+	PATTERN_CLOCK          = "clock"
+	DEFAULT_PATTERN_WIDTH  = 640
+	DEFAULT_PATTERN_HEIGHT = 480
+)
+
+// SMPTE like color bars, from left to right
+var patternBars = []color.RGBA{
+	{R: 192, G: 192, B: 192},
+	{R: 192, G: 192, B: 0},
+	{R: 0, G: 192, B: 192},
+	{R: 0, G: 192, B: 0},
+	{R: 192, G: 0, B: 192},
+	{R: 192, G: 0, B: 0},
+	{R: 0, G: 0, B: 192},
+}
+
+// IsPattern reports whether name is a pattern generated by virtual cameras.
+func IsPattern(name string) bool {
+	return name == "" || name == PATTERN_BARS || name == PATTERN_CLOCK
+}
+
+// patternSource generates frames without any device. Both patterns keep
+// something moving so motion detection and frame drops can be observed:
+// color bars with a square sweeping the bottom of the frame, or a clock with
+// a seconds hand.
+type patternSource struct {
+	name   string
+	width  int
+	height int
+	fps    int
+	pacer  *pacer
+	frame  int
+	// The camera may close the source while a frame is drawn
+	mu     sync.Mutex
+	base   gocv.Mat
+	opened bool
+}
+
+func newPatternSource(name string, config *config.CameraConfig) (*patternSource, error) {
+	if name == "" {
+		name = PATTERN_BARS
+	}
+	if !IsPattern(name) {
+		return nil, fmt.Errorf("unknown pattern %s", name)
+	}
+
+	width, height := config.Width, config.Height
+	if width <= 0 || height <= 0 {
+		width, height = DEFAULT_PATTERN_WIDTH, DEFAULT_PATTERN_HEIGHT
+	}
+
+	fps := sourceFPS(config)
+	return &patternSource{
+		name:   name,
+		width:  width,
+		height: height,
+		fps:    fps,
+		pacer:  newPacer(float64(fps)),
+	}, nil
+}
+
+func (s *patternSource) Open() (camera.Infos, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.base = gocv.NewMatWithSize(s.height, s.width, gocv.MatTypeCV8UC3)
+	s.opened = true
+
+	switch s.name {
+	case PATTERN_BARS:
+		barsHeight := s.height * 2 / 3
+		for i, c := range patternBars {
+			bar := image.Rect(i*s.width/len(patternBars), 0, (i+1)*s.width/len(patternBars), barsHeight)
+			gocv.Rectangle(&s.base, bar, c, -1)
+		}
+		gocv.Rectangle(&s.base, image.Rect(0, barsHeight, s.width, s.height), color.RGBA{R: 16, G: 16, B: 16}, -1)
+	case PATTERN_CLOCK:
+		s.base.SetTo(gocv.NewScalar(32, 24, 16, 0))
+		gocv.Circle(&s.base, s.clockCenter(), s.clockRadius(), color.RGBA{R: 220, G: 220, B: 220}, 3)
+		for hour := 0; hour < 12; hour++ {
+			gocv.Line(&s.base, s.clockPoint(float64(hour)/12, 0.85), s.clockPoint(float64(hour)/12, 1), color.RGBA{R: 220, G: 220, B: 220}, 3)
+		}
+	}
+
+	return camera.Infos{
+		DeviceID: s.name,
+		Width:    s.width,
+		Height:   s.height,
+		FPS:      float64(s.fps),
+	}, nil
+}
+
+func (s *patternSource) clockCenter() image.Point {
+	return image.Point{X: s.width / 2, Y: s.height / 2}
+}
+
+func (s *patternSource) clockRadius() int {
+	return min(s.width, s.height) * 2 / 5
+}
+
+// clockPoint returns the point at turn (0 to 1, clockwise from 12 o'clock)
+// and distance (relative to the radius) from the clock center.
+func (s *patternSource) clockPoint(turn, distance float64) image.Point {
+	center := s.clockCenter()
+	angle := turn * 2 * math.Pi
+	length := distance * float64(s.clockRadius())
+	return image.Point{
+		X: center.X + int(length*math.Sin(angle)),
+		Y: center.Y - int(length*math.Cos(angle)),
+	}
+}
+
+func (s *patternSource) Read(img *gocv.Mat) error {
+	s.pacer.wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.opened {
+		return ErrCameraClosed
+	}
+	s.base.CopyTo(img)
+
+	switch s.name {
+	case PATTERN_BARS:
+		// The square crosses the frame every 4 seconds
+		size := s.height / 6
+		span := s.width - size
+		step := s.frame % (4 * s.fps)
+		x := span * step / (4 * s.fps)
+		y := s.height*2/3 + (s.height/3-size)/2
+		gocv.Rectangle(img, image.Rect(x, y, x+size, y+size), color.RGBA{R: 255, G: 255, B: 255}, -1)
+
+		gocv.PutText(img, fmt.Sprintf("frame %d", s.frame), image.Point{X: 10, Y: 30}, gocv.FontHersheyPlain, 2, color.RGBA{R: 0, G: 0, B: 0}, 2)
+	case PATTERN_CLOCK:
+		now := time.Now()
+		seconds := float64(now.Second()) + float64(now.Nanosecond())/1e9
+		minutes := float64(now.Minute()) + seconds/60
+		hours := float64(now.Hour()%12) + minutes/60
+
+		center := s.clockCenter()
+		gocv.Line(img, center, s.clockPoint(hours/12, 0.5), color.RGBA{R: 255, G: 255, B: 255}, 6)
+		gocv.Line(img, center, s.clockPoint(minutes/60, 0.75), color.RGBA{R: 255, G: 255, B: 255}, 4)
+		gocv.Line(img, center, s.clockPoint(seconds/60, 0.9), color.RGBA{R: 255, G: 64, B: 64}, 2)
+
+		gocv.PutText(img, now.Format("15:04:05"), image.Point{X: 10, Y: 30}, gocv.FontHersheyPlain, 2, color.RGBA{R: 255, G: 255, B: 255}, 2)
+	}
+
+	s.frame++
+	return nil
+}
+
+func (s *patternSource) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.opened {
+		return nil
+	}
+	s.opened = false
+	return s.base.Close()
+}
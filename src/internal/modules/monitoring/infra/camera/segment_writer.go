@@ -0,0 +1,146 @@
+package camera
+
+import (
+	"fmt"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	"monitoring-system/src/pkg/logger"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gocv.io/x/gocv"
+)
+
+const segmentSizeCheckInterval = time.Second
+
+// Segments started in the same millisecond get a numeric suffix, up to this one
+const maxSegmentSequence = 100
+
+// segmentWriter splits a recording into fixed-length files, opening a new
+// gocv.VideoWriter whenever the current segment hits its duration or size limit.
+type segmentWriter struct {
+	cameraID      string
+	codec         string
+	fps           float64
+	opts          recording.Options
+	logger        logger.Logger
+	writer        *gocv.VideoWriter
+	segment       recording.Segment
+	lastSizeCheck time.Time
+	// pendingMotion flags the next segment when motion fires between two
+	pendingMotion bool
+}
+
+func newSegmentWriter(cameraID, codec string, fps float64, opts recording.Options, logger logger.Logger) *segmentWriter {
+	return &segmentWriter{
+		cameraID: cameraID,
+		codec:    codec,
+		fps:      fps,
+		opts:     opts,
+		logger:   logger,
+	}
+}
+
+func (s *segmentWriter) Write(img gocv.Mat) error {
+	if s.writer != nil && s.shouldRotate() {
+		if err := s.Close(); err != nil {
+			s.logger.Error("Error closing segment %s: %v", s.segment.Path, err)
+		}
+	}
+
+	if s.writer == nil {
+		if err := s.open(img.Cols(), img.Rows()); err != nil {
+			return err
+		}
+	}
+
+	if err := s.writer.Write(img); err != nil {
+		return fmt.Errorf("error writing frame to %s: %v", s.segment.Path, err)
+	}
+	return nil
+}
+
+func (s *segmentWriter) open(width, height int) error {
+	start := time.Now()
+	path, err := s.freePath(start)
+	if err != nil {
+		return err
+	}
+
+	// The writer is opened on the first frame so its size matches what the device actually delivers
+	writer, err := gocv.VideoWriterFile(path, s.codec, s.fps, width, height, true)
+	if err != nil {
+		return fmt.Errorf("error opening segment %s: %v", path, err)
+	}
+
+	s.writer = writer
+	s.segment = recording.Segment{
+		CameraID: s.cameraID,
+		Path:     path,
+		Start:    start,
+		Motion:   s.pendingMotion,
+	}
+	s.pendingMotion = false
+	s.lastSizeCheck = start
+	return nil
+}
+
+// freePath returns a segment path that does not exist yet, never overwriting a
+// segment started in the same millisecond.
+func (s *segmentWriter) freePath(start time.Time) (string, error) {
+	for sequence := 0; sequence <= maxSegmentSequence; sequence++ {
+		path := filepath.Join(s.opts.Dir, recording.SegmentFileName(s.cameraID, start, sequence))
+		_, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("error opening segment %s: %v", path, err)
+		}
+	}
+	return "", fmt.Errorf("error opening segment of camera %s: %d segments already started at %s", s.cameraID, maxSegmentSequence+1, start.Format(time.RFC3339Nano))
+}
+
+// markMotion flags the current segment as containing motion, or the next
+// one when no segment is open.
+func (s *segmentWriter) markMotion() {
+	if s.writer == nil {
+		s.pendingMotion = true
+		return
+	}
+	s.segment.Motion = true
+}
+
+func (s *segmentWriter) shouldRotate() bool {
+	if s.opts.SegmentDuration > 0 && time.Since(s.segment.Start) >= s.opts.SegmentDuration {
+		return true
+	}
+
+	if s.opts.MaxSegmentSize > 0 && time.Since(s.lastSizeCheck) >= segmentSizeCheckInterval {
+		s.lastSizeCheck = time.Now()
+		if info, err := os.Stat(s.segment.Path); err == nil && info.Size() >= s.opts.MaxSegmentSize {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *segmentWriter) Close() error {
+	if s.writer == nil {
+		return nil
+	}
+
+	err := s.writer.Close()
+	s.writer = nil
+
+	s.segment.End = time.Now()
+	if info, statErr := os.Stat(s.segment.Path); statErr == nil {
+		s.segment.Size = info.Size()
+	}
+
+	if s.opts.OnSegment != nil {
+		s.opts.OnSegment(s.segment)
+	}
+	return err
+}
@@ -0,0 +1,42 @@
+package camera
+
+import (
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFreePathAddsSequence(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 5, 1, 12, 30, 15, 0, time.Local)
+	for sequence := 0; sequence < 2; sequence++ {
+		if err := os.WriteFile(filepath.Join(dir, recording.SegmentFileName("cam", start, sequence)), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := newSegmentWriter("cam", "MJPG", 10, recording.Options{Dir: dir}, newTestLogger(t))
+	path, err := s.freePath(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, recording.SegmentFileName("cam", start, 2)); path != want {
+		t.Errorf("freePath = %q, want %q", path, want)
+	}
+}
+
+func TestFreePathReturnsStatErrors(t *testing.T) {
+	// A file where the recordings directory should be fails with ENOTDIR
+	dir := filepath.Join(t.TempDir(), "recordings")
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newSegmentWriter("cam", "MJPG", 10, recording.Options{Dir: dir}, newTestLogger(t))
+	if _, err := s.freePath(time.Now()); err == nil || !strings.Contains(err.Error(), "error opening segment") {
+		t.Errorf("freePath = %v, want the stat error", err)
+	}
+}
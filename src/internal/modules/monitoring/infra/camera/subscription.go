@@ -0,0 +1,53 @@
+package camera
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var ErrCameraClosed = errors.New("camera closed")
+
+type frameSubscription struct {
+	camera    *Camera
+	seq       uint64
+	closeOnce sync.Once
+}
+
+func newFrameSubscription(camera *Camera) *frameSubscription {
+	camera.jpegs.acquire()
+	return &frameSubscription{
+		camera: camera,
+		seq:    camera.jpegs.current(),
+	}
+}
+
+// Capture blocks until a frame newer than the last one returned is encoded.
+// The returned slice is shared with other viewers and must not be modified.
+func (s *frameSubscription) Capture() ([]byte, error) {
+	return s.capture(s.camera.ctx)
+}
+
+func (s *frameSubscription) capture(ctx context.Context) ([]byte, error) {
+	for {
+		frame, seq, wait := s.camera.jpegs.next(s.seq)
+		if wait == nil {
+			s.seq = seq
+			return frame, nil
+		}
+
+		select {
+		case <-s.camera.done:
+			return nil, ErrCameraClosed
+		case <-s.camera.ctx.Done():
+			return nil, ErrCameraClosed
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-wait:
+		}
+	}
+}
+
+func (s *frameSubscription) Close() {
+	s.closeOnce.Do(s.camera.jpegs.release)
+}
@@ -0,0 +1,80 @@
+package camera
+
+import (
+	"fmt"
+	"io"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+
+	"gocv.io/x/gocv"
+)
+
+// videoSource plays a video file at its own frame rate, OpenCV would
+// otherwise decode it as fast as it can. Once the end is reached it starts
+// over, or reports io.EOF when not looping.
+type videoSource struct {
+	path   string
+	loop   bool
+	config *config.CameraConfig
+	video  *gocv.VideoCapture
+	pacer  *pacer
+}
+
+func newVideoSource(path string, loop bool, config *config.CameraConfig) *videoSource {
+	return &videoSource{path: path, loop: loop, config: config}
+}
+
+func (s *videoSource) Open() (camera.Infos, error) {
+	video, err := gocv.VideoCaptureFile(s.path)
+	if err != nil {
+		return camera.Infos{}, err
+	}
+	s.video = video
+
+	if !video.IsOpened() {
+		return camera.Infos{}, fmt.Errorf("unable to open video %s", s.path)
+	}
+
+	width := video.Get(gocv.VideoCaptureFrameWidth)
+	height := video.Get(gocv.VideoCaptureFrameHeight)
+	if width == 0 || height == 0 {
+		return camera.Infos{}, fmt.Errorf("unable to get dimensions of video %s", s.path)
+	}
+
+	fps := video.Get(gocv.VideoCaptureFPS)
+	if fps <= 0 {
+		fps = float64(sourceFPS(s.config))
+	}
+	s.pacer = newPacer(fps)
+
+	return camera.Infos{
+		DeviceID: s.path,
+		Width:    int(width),
+		Height:   int(height),
+		FPS:      fps,
+	}, nil
+}
+
+func (s *videoSource) Read(img *gocv.Mat) error {
+	s.pacer.wait()
+
+	if ok := s.video.Read(img); ok && !img.Empty() {
+		return nil
+	}
+	if !s.loop {
+		return io.EOF
+	}
+
+	s.video.Set(gocv.VideoCapturePosFrames, 0)
+	if ok := s.video.Read(img); !ok || img.Empty() {
+		return ErrFrameUnavailable
+	}
+	return nil
+}
+
+func (s *videoSource) Close() error {
+	if s.video != nil {
+		return s.video.Close()
+	}
+	return nil
+}
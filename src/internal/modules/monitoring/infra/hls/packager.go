@@ -0,0 +1,156 @@
+package hls
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/pkg/logger"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	PLAYLIST_NAME   = "index.m3u8"
+	SEGMENT_PATTERN = "segment_%05d.ts"
+	DEFAULT_FPS     = 15
+)
+
+// Packager pipes the JPEG frames of a camera through ffmpeg, which encodes
+// them to H.264 and maintains a rolling HLS playlist in dir.
+type Packager struct {
+	cam    camera.CameraService
+	dir    string
+	config *config.HLSConfig
+	logger logger.Logger
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewPackager(cam camera.CameraService, dir string, config *config.HLSConfig, logger logger.Logger) *Packager {
+	return &Packager{
+		cam:    cam,
+		dir:    dir,
+		config: config,
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+}
+
+func (p *Packager) args(fps int) []string {
+	segment := p.config.SegmentDuration.Seconds()
+	if segment <= 0 {
+		segment = 2
+	}
+	playlistSize := p.config.PlaylistSize
+	if playlistSize <= 0 {
+		playlistSize = 6
+	}
+
+	return []string{
+		"-loglevel", "error",
+		"-f", "image2pipe",
+		"-use_wallclock_as_timestamps", "1",
+		"-c:v", "mjpeg",
+		"-i", "-",
+		"-an",
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-tune", "zerolatency",
+		"-pix_fmt", "yuv420p",
+		"-r", strconv.Itoa(fps),
+		// Key frames on segment boundaries so every segment can be played alone
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%g)", segment),
+		"-f", "hls",
+		"-hls_time", strconv.FormatFloat(segment, 'f', -1, 64),
+		"-hls_list_size", strconv.Itoa(playlistSize),
+		"-hls_flags", "delete_segments+independent_segments",
+		"-hls_segment_filename", filepath.Join(p.dir, SEGMENT_PATTERN),
+		filepath.Join(p.dir, PLAYLIST_NAME),
+	}
+}
+
+func (p *Packager) Start(ctx context.Context) error {
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return fmt.Errorf("error creating HLS directory %s: %v", p.dir, err)
+	}
+
+	fps := int(math.Round(p.cam.GetDetails().Infos.FPS))
+	if fps <= 0 {
+		fps = DEFAULT_FPS
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+
+	cmd := exec.CommandContext(ctx, p.config.FFmpegPath, p.args(fps)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("error starting ffmpeg: %v", err)
+	}
+
+	go p.feed(ctx, stdin)
+
+	go func() {
+		defer close(p.done)
+		defer cancel()
+
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+			p.logger.Error("ffmpeg exited for camera %s: %v %s", p.cam.GetDetails().ID, err, strings.TrimSpace(stderr.String()))
+		}
+		os.RemoveAll(p.dir)
+	}()
+
+	return nil
+}
+
+func (p *Packager) feed(ctx context.Context, stdin io.WriteCloser) {
+	defer stdin.Close()
+
+	sub := p.cam.Subscribe()
+	defer sub.Close()
+
+	for ctx.Err() == nil {
+		img, err := sub.Capture()
+		if err != nil {
+			return
+		}
+		if _, err := stdin.Write(img); err != nil {
+			return
+		}
+	}
+}
+
+func (p *Packager) PlaylistPath() string {
+	return filepath.Join(p.dir, PLAYLIST_NAME)
+}
+
+func (p *Packager) Dir() string {
+	return p.dir
+}
+
+func (p *Packager) Done() <-chan struct{} {
+	return p.done
+}
+
+// Close stops ffmpeg and removes the segments.
+func (p *Packager) Close() {
+	if p.cancel != nil {
+		p.cancel()
+		<-p.done
+	}
+}
@@ -0,0 +1,263 @@
+package motion
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+
+	"gocv.io/x/gocv"
+)
+
+// Defaults of OpenCV, and of the detector before it was configurable
+const (
+	DEFAULT_HISTORY             = 500
+	DEFAULT_MOG2_VAR_THRESHOLD  = 16
+	DEFAULT_KNN_DIST2_THRESHOLD = 400
+	DEFAULT_THRESHOLD           = 25
+	DEFAULT_KERNEL_SIZE         = 3
+	DIFF_BLUR_SIZE              = 21
+	DEFAULT_DETECT_SHADOWS      = true
+	// MOG2 and KNN mark shadow pixels with this value
+	SHADOW_VALUE = 127
+)
+
+// subtractor is implemented by the background subtractors of gocv.
+type subtractor interface {
+	Apply(src gocv.Mat, dst *gocv.Mat) error
+	Close() error
+}
+
+// frameDiff is a subtractor using the previous frame as background.
+type frameDiff struct {
+	gray     gocv.Mat
+	previous gocv.Mat
+}
+
+func newFrameDiff() *frameDiff {
+	return &frameDiff{gray: gocv.NewMat(), previous: gocv.NewMat()}
+}
+
+func (f *frameDiff) Apply(src gocv.Mat, dst *gocv.Mat) error {
+	if err := gocv.CvtColor(src, &f.gray, gocv.ColorBGRToGray); err != nil {
+		return err
+	}
+	if err := gocv.GaussianBlur(f.gray, &f.gray, image.Pt(DIFF_BLUR_SIZE, DIFF_BLUR_SIZE), 0, 0, gocv.BorderDefault); err != nil {
+		return err
+	}
+
+	// The first frame, or a new size, only becomes the background
+	if f.previous.Empty() || f.previous.Cols() != f.gray.Cols() || f.previous.Rows() != f.gray.Rows() {
+		f.gray.CopyTo(&f.previous)
+		f.gray.CopyTo(dst)
+		dst.SetTo(gocv.NewScalar(0, 0, 0, 0))
+		return nil
+	}
+
+	err := gocv.AbsDiff(f.gray, f.previous, dst)
+	f.gray.CopyTo(&f.previous)
+	return err
+}
+
+func (f *frameDiff) Close() error {
+	f.gray.Close()
+	return f.previous.Close()
+}
+
+// Detector runs background subtraction over consecutive frames of a single
+// camera. It keeps state between frames and is not safe for concurrent use.
+type Detector struct {
+	minArea   float64
+	threshold float32
+	minFrames int
+	// Consecutive frames with motion so far
+	streak    int
+	subtract  subtractor
+	imgDelta  gocv.Mat
+	imgThresh gocv.Mat
+	kernel    gocv.Mat
+	zones     []motion.Zone
+	// mask is built from the zones for the size of the frames, it is empty
+	// when the whole frame is watched
+	mask     gocv.Mat
+	maskSize image.Point
+}
+
+// NewDetector creates the detector configured for a camera, zero values of
+// cfg fall back to the defaults above.
+func NewDetector(minArea int, cfg config.MotionConfig) (*Detector, error) {
+	history := cfg.History
+	if history <= 0 {
+		history = DEFAULT_HISTORY
+	}
+	shadows := DEFAULT_DETECT_SHADOWS
+	if cfg.DetectShadows != nil {
+		shadows = *cfg.DetectShadows
+	}
+
+	var subtract subtractor
+	switch motion.Algorithm(cfg.Algorithm) {
+	case "", motion.AlgorithmMOG2:
+		varThreshold := cfg.VarThreshold
+		if varThreshold <= 0 {
+			varThreshold = DEFAULT_MOG2_VAR_THRESHOLD
+		}
+		mog2 := gocv.NewBackgroundSubtractorMOG2WithParams(history, varThreshold, shadows)
+		subtract = &mog2
+	case motion.AlgorithmKNN:
+		dist2Threshold := cfg.VarThreshold
+		if dist2Threshold <= 0 {
+			dist2Threshold = DEFAULT_KNN_DIST2_THRESHOLD
+		}
+		knn := gocv.NewBackgroundSubtractorKNNWithParams(history, dist2Threshold, shadows)
+		subtract = &knn
+	case motion.AlgorithmDiff:
+		subtract = newFrameDiff()
+	default:
+		return nil, fmt.Errorf("unknown motion algorithm %s", cfg.Algorithm)
+	}
+
+	threshold := cfg.Threshold
+	if threshold <= 0 {
+		threshold = DEFAULT_THRESHOLD
+	}
+	// Detected shadows are not motion, only pixels above the threshold count
+	if shadows && motion.Algorithm(cfg.Algorithm) != motion.AlgorithmDiff {
+		threshold = max(threshold, SHADOW_VALUE)
+	}
+	kernelSize := cfg.KernelSize
+	if kernelSize <= 0 {
+		kernelSize = DEFAULT_KERNEL_SIZE
+	}
+
+	return &Detector{
+		minArea:   float64(minArea),
+		threshold: float32(threshold),
+		minFrames: max(1, cfg.MinFrames),
+		subtract:  subtract,
+		imgDelta:  gocv.NewMat(),
+		imgThresh: gocv.NewMat(),
+		kernel:    gocv.GetStructuringElement(gocv.MorphRect, image.Pt(kernelSize, kernelSize)),
+		mask:      gocv.NewMat(),
+	}, nil
+}
+
+// SetZones restricts detection to the enabled zones, the mask is built again
+// on the next frame.
+func (d *Detector) SetZones(zones []motion.Zone) {
+	var active []motion.Zone
+	for _, zone := range zones {
+		if zone.Enabled && len(zone.Points) >= 3 {
+			active = append(active, zone)
+		}
+	}
+	d.zones = active
+	d.maskSize = image.Point{}
+}
+
+// updateMask reports whether the frames have to be masked. Without include
+// zones the whole frame is watched, exclude zones are then cut out.
+func (d *Detector) updateMask(width, height int) bool {
+	if len(d.zones) == 0 {
+		return false
+	}
+	if d.maskSize == image.Pt(width, height) {
+		return true
+	}
+
+	var include, exclude [][]image.Point
+	for _, zone := range d.zones {
+		polygon := make([]image.Point, len(zone.Points))
+		for i, point := range zone.Points {
+			polygon[i] = image.Pt(int(point.X*float64(width)), int(point.Y*float64(height)))
+		}
+		if zone.Kind == motion.ZoneExclude {
+			exclude = append(exclude, polygon)
+		} else {
+			include = append(include, polygon)
+		}
+	}
+
+	d.mask.Close()
+	d.mask = gocv.Zeros(height, width, gocv.MatTypeCV8U)
+	if len(include) == 0 {
+		d.mask.SetTo(gocv.NewScalar(255, 0, 0, 0))
+	} else {
+		fillPolygons(&d.mask, include, color.RGBA{R: 255})
+	}
+	if len(exclude) > 0 {
+		fillPolygons(&d.mask, exclude, color.RGBA{})
+	}
+
+	d.maskSize = image.Pt(width, height)
+	return true
+}
+
+func fillPolygons(img *gocv.Mat, polygons [][]image.Point, c color.RGBA) {
+	points := gocv.NewPointsVectorFromPoints(polygons)
+	defer points.Close()
+	gocv.FillPoly(img, points, c)
+}
+
+func (d *Detector) Detect(img gocv.Mat) motion.Detection {
+	if err := d.subtract.Apply(img, &d.imgDelta); err != nil {
+		// The frame is skipped, the background model did not take it
+		return motion.Detection{}
+	}
+
+	gocv.Threshold(d.imgDelta, &d.imgThresh, d.threshold, 255, gocv.ThresholdBinary)
+	gocv.Dilate(d.imgThresh, &d.imgThresh, d.kernel)
+
+	// Motion outside the zones is dropped before contours are measured, so
+	// only the part of a contour inside the zones counts towards its area
+	if d.updateMask(img.Cols(), img.Rows()) {
+		gocv.BitwiseAnd(d.imgThresh, d.mask, &d.imgThresh)
+	}
+
+	contours := gocv.FindContours(d.imgThresh, gocv.RetrievalExternal, gocv.ChainApproxSimple)
+	defer contours.Close()
+
+	var detection motion.Detection
+	var motionArea float64
+	for i := 0; i < contours.Size(); i++ {
+		contour := contours.At(i)
+		area := gocv.ContourArea(contour)
+		if area < d.minArea {
+			continue
+		}
+
+		rect := gocv.BoundingRect(contour)
+		detection.Boxes = append(detection.Boxes, motion.BoundingBox{
+			X:      rect.Min.X,
+			Y:      rect.Min.Y,
+			Width:  rect.Dx(),
+			Height: rect.Dy(),
+		})
+		motionArea += area
+	}
+
+	if frameArea := float64(img.Cols() * img.Rows()); frameArea > 0 {
+		detection.Score = motionArea / frameArea
+	}
+
+	// Flickering lights and noise rarely last, real motion spans frames
+	if !detection.Motion() {
+		d.streak = 0
+		return detection
+	}
+	d.streak++
+	if d.streak < d.minFrames {
+		return motion.Detection{}
+	}
+
+	return detection
+}
+
+func (d *Detector) Close() {
+	d.subtract.Close()
+	d.imgDelta.Close()
+	d.imgThresh.Close()
+	d.kernel.Close()
+	d.mask.Close()
+}
@@ -0,0 +1,35 @@
+package motion
+
+import (
+	"monitoring-system/src/config"
+	"testing"
+)
+
+func TestDetectorThresholdDropsShadows(t *testing.T) {
+	off := false
+	tests := []struct {
+		name string
+		cfg  config.MotionConfig
+		want float32
+	}{
+		{"mog2 with default shadows", config.MotionConfig{}, SHADOW_VALUE},
+		{"knn with default shadows", config.MotionConfig{Algorithm: "knn"}, SHADOW_VALUE},
+		{"higher threshold kept", config.MotionConfig{Threshold: 200}, 200},
+		{"shadows disabled", config.MotionConfig{DetectShadows: &off}, DEFAULT_THRESHOLD},
+		{"frame difference has no shadows", config.MotionConfig{Algorithm: "diff"}, DEFAULT_THRESHOLD},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector, err := NewDetector(100, tt.cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer detector.Close()
+
+			if detector.threshold != tt.want {
+				t.Errorf("threshold = %v, want %v", detector.threshold, tt.want)
+			}
+		})
+	}
+}
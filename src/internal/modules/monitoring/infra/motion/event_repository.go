@@ -0,0 +1,147 @@
+package motion
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/pkg/logger"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type eventRepository struct {
+	sqlDB  *sql.DB
+	logger logger.Logger
+}
+
+func NewEventRepository(ctx context.Context, db *sql.DB, logger logger.Logger) (motion.EventRepository, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS motion_events (
+			id         VARCHAR(36) PRIMARY KEY,
+			camera_id  VARCHAR(255) NOT NULL,
+			start_time INTEGER NOT NULL,
+			end_time   INTEGER,
+			score      REAL NOT NULL,
+			boxes      TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_motion_events_camera_start ON motion_events (camera_id, start_time);
+	`)
+	if err != nil {
+		logger.Error("Error creating motion_events table: %v", err)
+		return nil, err
+	}
+
+	return &eventRepository{sqlDB: db, logger: logger}, nil
+}
+
+func (r *eventRepository) Save(ctx context.Context, event motion.Event) (motion.Event, error) {
+	if event.ID == "" {
+		event.ID = uuid.New().String()
+	}
+
+	boxes, err := json.Marshal(event.Boxes)
+	if err != nil {
+		return motion.Event{}, err
+	}
+
+	var end sql.NullInt64
+	if event.End != nil {
+		end = sql.NullInt64{Int64: event.End.UnixMilli(), Valid: true}
+	}
+
+	_, err = r.sqlDB.ExecContext(ctx, `
+		INSERT INTO motion_events (id, camera_id, start_time, end_time, score, boxes)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET end_time = excluded.end_time, score = excluded.score, boxes = excluded.boxes
+	`, event.ID, event.CameraID, event.Start.UnixMilli(), end, event.Score, string(boxes))
+	if err != nil {
+		r.logger.Error("Error saving motion event %s: %v", event.ID, err)
+		return motion.Event{}, err
+	}
+
+	return event, nil
+}
+
+func (r *eventRepository) List(ctx context.Context, filter motion.Filter) (motion.EventPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+	if filter.PageSize > maxPageSize {
+		filter.PageSize = maxPageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	if filter.CameraID != "" {
+		conditions = append(conditions, "camera_id = ?")
+		args = append(args, filter.CameraID)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "(end_time IS NULL OR end_time >= ?)")
+		args = append(args, filter.From.UnixMilli())
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "start_time <= ?")
+		args = append(args, filter.To.UnixMilli())
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	page := motion.EventPage{
+		Items:    []motion.Event{},
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}
+
+	if err := r.sqlDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM motion_events"+where, args...).Scan(&page.Total); err != nil {
+		r.logger.Error("Error counting motion events: %v", err)
+		return motion.EventPage{}, err
+	}
+
+	query := "SELECT id, camera_id, start_time, end_time, score, boxes FROM motion_events" + where + " ORDER BY start_time DESC LIMIT ? OFFSET ?"
+	rows, err := r.sqlDB.QueryContext(ctx, query, append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)...)
+	if err != nil {
+		r.logger.Error("Error querying motion events: %v", err)
+		return motion.EventPage{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event motion.Event
+		var start int64
+		var end sql.NullInt64
+		var boxes string
+
+		if err := rows.Scan(&event.ID, &event.CameraID, &start, &end, &event.Score, &boxes); err != nil {
+			r.logger.Error("Error scanning motion event: %v", err)
+			return motion.EventPage{}, err
+		}
+
+		event.Start = time.UnixMilli(start)
+		if end.Valid {
+			endTime := time.UnixMilli(end.Int64)
+			event.End = &endTime
+		}
+		if err := json.Unmarshal([]byte(boxes), &event.Boxes); err != nil {
+			r.logger.Error("Error decoding motion event %s boxes: %v", event.ID, err)
+		}
+
+		page.Items = append(page.Items, event)
+	}
+
+	return page, rows.Err()
+}
@@ -0,0 +1,100 @@
+package motion
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/pkg/logger"
+	"time"
+)
+
+type zoneRepository struct {
+	sqlDB  *sql.DB
+	logger logger.Logger
+}
+
+func NewZoneRepository(ctx context.Context, db *sql.DB, logger logger.Logger) (motion.ZoneRepository, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS motion_zones (
+			id         VARCHAR(36) PRIMARY KEY,
+			camera_id  VARCHAR(255) NOT NULL,
+			name       VARCHAR(255) NOT NULL,
+			kind       VARCHAR(16) NOT NULL,
+			points     TEXT NOT NULL,
+			enabled    BOOLEAN NOT NULL DEFAULT 1,
+			created_at INTEGER NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_motion_zones_camera ON motion_zones (camera_id);
+	`)
+	if err != nil {
+		logger.Error("Error creating motion_zones table: %v", err)
+		return nil, err
+	}
+
+	return &zoneRepository{sqlDB: db, logger: logger}, nil
+}
+
+// List returns the zones of a camera, or of every camera when cameraID is
+// empty.
+func (r *zoneRepository) List(ctx context.Context, cameraID string) ([]motion.Zone, error) {
+	query := "SELECT id, camera_id, name, kind, points, enabled, created_at FROM motion_zones"
+	var args []interface{}
+	if cameraID != "" {
+		query += " WHERE camera_id = ?"
+		args = append(args, cameraID)
+	}
+
+	rows, err := r.sqlDB.QueryContext(ctx, query+" ORDER BY created_at", args...)
+	if err != nil {
+		r.logger.Error("Error querying motion zones: %v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	zones := []motion.Zone{}
+	for rows.Next() {
+		var zone motion.Zone
+		var points string
+		var createdAt int64
+
+		if err := rows.Scan(&zone.ID, &zone.CameraID, &zone.Name, &zone.Kind, &points, &zone.Enabled, &createdAt); err != nil {
+			r.logger.Error("Error scanning motion zone: %v", err)
+			return nil, err
+		}
+		zone.CreatedAt = time.UnixMilli(createdAt)
+		if err := json.Unmarshal([]byte(points), &zone.Points); err != nil {
+			r.logger.Error("Error decoding motion zone %s points: %v", zone.ID, err)
+		}
+
+		zones = append(zones, zone)
+	}
+
+	return zones, rows.Err()
+}
+
+func (r *zoneRepository) Save(ctx context.Context, zone motion.Zone) error {
+	points, err := json.Marshal(zone.Points)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.sqlDB.ExecContext(ctx, `
+		INSERT INTO motion_zones (id, camera_id, name, kind, points, enabled, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET name = excluded.name, kind = excluded.kind, points = excluded.points, enabled = excluded.enabled
+	`, zone.ID, zone.CameraID, zone.Name, zone.Kind, string(points), zone.Enabled, zone.CreatedAt.UnixMilli())
+	if err != nil {
+		r.logger.Error("Error saving motion zone %s: %v", zone.ID, err)
+		return err
+	}
+	return nil
+}
+
+func (r *zoneRepository) Delete(ctx context.Context, id string) error {
+	if _, err := r.sqlDB.ExecContext(ctx, "DELETE FROM motion_zones WHERE id = ?", id); err != nil {
+		r.logger.Error("Error deleting motion zone %s: %v", id, err)
+		return err
+	}
+	return nil
+}
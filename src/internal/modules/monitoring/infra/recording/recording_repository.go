@@ -0,0 +1,172 @@
+package recording
+
+import (
+	"context"
+	"database/sql"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	"monitoring-system/src/pkg/app_error"
+	"monitoring-system/src/pkg/logger"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type recordingRepository struct {
+	sqlDB  *sql.DB
+	logger logger.Logger
+}
+
+func NewRecordingRepository(ctx context.Context, db *sql.DB, logger logger.Logger) (recording.Repository, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS recordings (
+			id         VARCHAR(36) PRIMARY KEY,
+			camera_id  VARCHAR(255) NOT NULL,
+			start_time INTEGER NOT NULL,
+			end_time   INTEGER NOT NULL,
+			size       INTEGER NOT NULL,
+			motion     BOOLEAN NOT NULL DEFAULT 0,
+			path       TEXT NOT NULL UNIQUE
+		);
+		CREATE INDEX IF NOT EXISTS idx_recordings_camera_start ON recordings (camera_id, start_time);
+	`)
+	if err != nil {
+		logger.Error("Error creating recordings table: %v", err)
+		return nil, err
+	}
+
+	return &recordingRepository{sqlDB: db, logger: logger}, nil
+}
+
+func (r *recordingRepository) Save(ctx context.Context, segment recording.Segment) (recording.Segment, error) {
+	if segment.ID == "" {
+		segment.ID = uuid.New().String()
+	}
+
+	// Segments found again on disk keep their ID and motion flag, RETURNING
+	// gives back the ID of the stored row
+	err := r.sqlDB.QueryRowContext(ctx, `
+		INSERT INTO recordings (id, camera_id, start_time, end_time, size, motion, path)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(path) DO UPDATE SET end_time = excluded.end_time, size = excluded.size, motion = recordings.motion OR excluded.motion
+		RETURNING id
+	`, segment.ID, segment.CameraID, segment.Start.UnixMilli(), segment.End.UnixMilli(), segment.Size, segment.Motion, segment.Path).Scan(&segment.ID)
+	if err != nil {
+		r.logger.Error("Error saving recording %s: %v", segment.Path, err)
+		return recording.Segment{}, err
+	}
+
+	return segment, nil
+}
+
+func (r *recordingRepository) GetByID(ctx context.Context, id string) (*recording.Segment, error) {
+	row := r.sqlDB.QueryRowContext(ctx, "SELECT id, camera_id, start_time, end_time, size, motion, path FROM recordings WHERE id = ?", id)
+
+	segment, err := scanSegment(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, app_error.NewApiError(404, "Recording not found")
+		}
+		r.logger.Error("Error querying recording %s: %v", id, err)
+		return nil, err
+	}
+
+	return &segment, nil
+}
+
+func (r *recordingRepository) List(ctx context.Context, filter recording.Filter) (recording.SegmentPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultPageSize
+	}
+	if filter.PageSize > maxPageSize {
+		filter.PageSize = maxPageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	if filter.CameraID != "" {
+		conditions = append(conditions, "camera_id = ?")
+		args = append(args, filter.CameraID)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "end_time >= ?")
+		args = append(args, filter.From.UnixMilli())
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "start_time <= ?")
+		args = append(args, filter.To.UnixMilli())
+	}
+	if filter.Motion != nil {
+		conditions = append(conditions, "motion = ?")
+		args = append(args, *filter.Motion)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	page := recording.SegmentPage{
+		Items:    []recording.Segment{},
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}
+
+	if err := r.sqlDB.QueryRowContext(ctx, "SELECT COUNT(*) FROM recordings"+where, args...).Scan(&page.Total); err != nil {
+		r.logger.Error("Error counting recordings: %v", err)
+		return recording.SegmentPage{}, err
+	}
+
+	query := "SELECT id, camera_id, start_time, end_time, size, motion, path FROM recordings" + where + " ORDER BY start_time DESC LIMIT ? OFFSET ?"
+	rows, err := r.sqlDB.QueryContext(ctx, query, append(args, filter.PageSize, (filter.Page-1)*filter.PageSize)...)
+	if err != nil {
+		r.logger.Error("Error querying recordings: %v", err)
+		return recording.SegmentPage{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		segment, err := scanSegment(rows)
+		if err != nil {
+			r.logger.Error("Error scanning recording: %v", err)
+			return recording.SegmentPage{}, err
+		}
+		page.Items = append(page.Items, segment)
+	}
+
+	return page, rows.Err()
+}
+
+func (r *recordingRepository) DeleteByPath(ctx context.Context, path string) error {
+	_, err := r.sqlDB.ExecContext(ctx, "DELETE FROM recordings WHERE path = ?", path)
+	if err != nil {
+		r.logger.Error("Error deleting recording %s: %v", path, err)
+	}
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSegment(row scanner) (recording.Segment, error) {
+	var segment recording.Segment
+	var start, end int64
+
+	err := row.Scan(&segment.ID, &segment.CameraID, &start, &end, &segment.Size, &segment.Motion, &segment.Path)
+	if err != nil {
+		return recording.Segment{}, err
+	}
+
+	segment.Start = time.UnixMilli(start)
+	segment.End = time.UnixMilli(end)
+	return segment, nil
+}
@@ -0,0 +1,116 @@
+package recording
+
+import (
+	"context"
+	"database/sql"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	"monitoring-system/src/pkg/logger"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestRepository(t *testing.T) recording.Repository {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	log, err := logger.NewLogger("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repository, err := NewRecordingRepository(context.Background(), db, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repository
+}
+
+func TestSaveReturnsStoredID(t *testing.T) {
+	ctx := context.Background()
+	repository := newTestRepository(t)
+
+	start := time.Now().Add(-time.Minute)
+	first, err := repository.Save(ctx, recording.Segment{
+		CameraID: "cam",
+		Path:     "/recordings/cam/cam_1.avi",
+		Start:    start,
+		End:      start.Add(30 * time.Second),
+		Size:     100,
+		Motion:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A catalog sync finds the same file again, without motion information
+	second, err := repository.Save(ctx, recording.Segment{
+		CameraID: "cam",
+		Path:     "/recordings/cam/cam_1.avi",
+		Start:    start,
+		End:      start.Add(time.Minute),
+		Size:     200,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID != first.ID {
+		t.Errorf("Save returned %s, want the stored ID %s", second.ID, first.ID)
+	}
+
+	stored, err := repository.GetByID(ctx, first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Size != 200 || !stored.Motion {
+		t.Errorf("stored segment = %+v, want size 200 with motion kept", stored)
+	}
+}
+
+func TestListFiltersByMotion(t *testing.T) {
+	ctx := context.Background()
+	repository := newTestRepository(t)
+
+	start := time.Now().Add(-time.Hour)
+	for i, motion := range []bool{true, false, true} {
+		_, err := repository.Save(ctx, recording.Segment{
+			CameraID: "cam",
+			Path:     filepath.Join("/recordings/cam", string(rune('a'+i))+".avi"),
+			Start:    start.Add(time.Duration(i) * time.Minute),
+			End:      start.Add(time.Duration(i+1) * time.Minute),
+			Motion:   motion,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	withMotion := true
+	page, err := repository.List(ctx, recording.Filter{Motion: &withMotion})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 2 {
+		t.Errorf("got %d segments with motion, want 2", page.Total)
+	}
+	for _, segment := range page.Items {
+		if !segment.Motion {
+			t.Errorf("segment %s has no motion", segment.ID)
+		}
+	}
+
+	page, err = repository.List(ctx, recording.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page.Total != 3 {
+		t.Errorf("got %d segments without filter, want 3", page.Total)
+	}
+}
@@ -0,0 +1,109 @@
+package recording
+
+import (
+	"fmt"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	"monitoring-system/src/pkg/logger"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+type fileStorage struct {
+	basePath string
+	logger   logger.Logger
+}
+
+func NewFileStorage(basePath string, logger logger.Logger) (recording.Storage, error) {
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, fmt.Errorf("error creating recordings directory %s: %v", basePath, err)
+	}
+	return &fileStorage{basePath: basePath, logger: logger}, nil
+}
+
+func (s *fileStorage) Path() string {
+	return s.basePath
+}
+
+func (s *fileStorage) List() ([]recording.Segment, error) {
+	cameraDirs, err := os.ReadDir(s.basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var segments []recording.Segment
+	for _, dir := range cameraDirs {
+		if !dir.IsDir() {
+			continue
+		}
+
+		files, err := os.ReadDir(filepath.Join(s.basePath, dir.Name()))
+		if err != nil {
+			s.logger.Error("Error reading recordings of camera %s: %v", dir.Name(), err)
+			continue
+		}
+
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+
+			cameraID, start, err := recording.ParseSegmentFileName(file.Name())
+			if err != nil {
+				continue
+			}
+
+			info, err := file.Info()
+			if err != nil {
+				continue
+			}
+
+			segments = append(segments, recording.Segment{
+				CameraID: cameraID,
+				Path:     filepath.Join(s.basePath, dir.Name(), file.Name()),
+				Start:    start,
+				End:      info.ModTime(),
+				Size:     info.Size(),
+			})
+		}
+	}
+
+	return segments, nil
+}
+
+func (s *fileStorage) contains(path string) error {
+	rel, err := filepath.Rel(s.basePath, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("segment %s is outside of %s", path, s.basePath)
+	}
+	return nil
+}
+
+func (s *fileStorage) Open(segment recording.Segment) (*os.File, error) {
+	if err := s.contains(segment.Path); err != nil {
+		return nil, err
+	}
+	return os.Open(segment.Path)
+}
+
+func (s *fileStorage) Delete(segment recording.Segment) error {
+	if err := s.contains(segment.Path); err != nil {
+		return err
+	}
+
+	if err := os.Remove(segment.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileStorage) DiskUsage() (uint64, uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(s.basePath, &stat); err != nil {
+		return 0, 0, err
+	}
+
+	blockSize := uint64(stat.Bsize)
+	return stat.Blocks * blockSize, stat.Bavail * blockSize, nil
+}
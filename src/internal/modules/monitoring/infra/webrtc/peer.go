@@ -0,0 +1,90 @@
+package webrtc
+
+import (
+	"context"
+	"fmt"
+	"monitoring-system/src/config"
+	"sync"
+
+	pion "github.com/pion/webrtc/v4"
+)
+
+// Peer is a single viewer, negotiated in one round trip: the answer is only
+// returned once all the local ICE candidates are gathered.
+type Peer struct {
+	pc        *pion.PeerConnection
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func NewPeer(ctx context.Context, config *config.WebRTCConfig, track pion.TrackLocal, offer string) (*Peer, string, error) {
+	configuration := pion.Configuration{}
+	if len(config.ICEServers) > 0 {
+		configuration.ICEServers = []pion.ICEServer{{URLs: config.ICEServers}}
+	}
+
+	pc, err := pion.NewPeerConnection(configuration)
+	if err != nil {
+		return nil, "", err
+	}
+	p := &Peer{pc: pc, done: make(chan struct{})}
+
+	sender, err := pc.AddTrack(track)
+	if err != nil {
+		p.Close()
+		return nil, "", err
+	}
+
+	// RTCP has to be read for the interceptors (NACK, reports) to work
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			if _, _, err := sender.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	pc.OnConnectionStateChange(func(state pion.PeerConnectionState) {
+		if state == pion.PeerConnectionStateFailed || state == pion.PeerConnectionStateClosed {
+			p.Close()
+		}
+	})
+
+	if err := pc.SetRemoteDescription(pion.SessionDescription{Type: pion.SDPTypeOffer, SDP: offer}); err != nil {
+		p.Close()
+		return nil, "", fmt.Errorf("invalid offer: %v", err)
+	}
+
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		p.Close()
+		return nil, "", err
+	}
+
+	gathered := pion.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(answer); err != nil {
+		p.Close()
+		return nil, "", err
+	}
+
+	select {
+	case <-gathered:
+	case <-ctx.Done():
+		p.Close()
+		return nil, "", ctx.Err()
+	}
+
+	return p, pc.LocalDescription().SDP, nil
+}
+
+func (p *Peer) Done() <-chan struct{} {
+	return p.done
+}
+
+func (p *Peer) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+		p.pc.Close()
+	})
+}
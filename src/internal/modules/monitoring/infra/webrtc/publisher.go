@@ -0,0 +1,209 @@
+package webrtc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/pkg/logger"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	pion "github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/media/h264reader"
+)
+
+const (
+	DEFAULT_FPS = 15
+	// H264_FMTP matches the constrained baseline stream produced by ffmpeg,
+	// which every browser can decode
+	H264_FMTP = "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f"
+)
+
+var annexBStartCode = []byte{0x00, 0x00, 0x00, 0x01}
+
+// Publisher pipes the JPEG frames of a camera through ffmpeg and writes the
+// resulting H.264 access units to a track shared by all the peers watching
+// the camera.
+type Publisher struct {
+	cam    camera.CameraService
+	config *config.WebRTCConfig
+	logger logger.Logger
+	track  *pion.TrackLocalStaticSample
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewPublisher(cam camera.CameraService, config *config.WebRTCConfig, logger logger.Logger) (*Publisher, error) {
+	id := cam.GetDetails().ID
+	track, err := pion.NewTrackLocalStaticSample(pion.RTPCodecCapability{
+		MimeType:    pion.MimeTypeH264,
+		ClockRate:   90000,
+		SDPFmtpLine: H264_FMTP,
+	}, "video", "camera-"+id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Publisher{
+		cam:    cam,
+		config: config,
+		logger: logger,
+		track:  track,
+		done:   make(chan struct{}),
+	}, nil
+}
+
+func (p *Publisher) args(fps int) []string {
+	keyframe := p.config.KeyframeInterval.Seconds()
+	if keyframe <= 0 {
+		keyframe = 1
+	}
+
+	return []string{
+		"-loglevel", "error",
+		"-f", "image2pipe",
+		"-use_wallclock_as_timestamps", "1",
+		"-c:v", "mjpeg",
+		"-i", "-",
+		"-an",
+		"-c:v", "libx264",
+		"-preset", "ultrafast",
+		"-tune", "zerolatency",
+		"-profile:v", "baseline",
+		"-pix_fmt", "yuv420p",
+		"-r", strconv.Itoa(fps),
+		// Frequent key frames so new viewers don't wait for a picture
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%g)", keyframe),
+		// Delimiters mark where each frame starts in the raw stream
+		"-bsf:v", "h264_metadata=aud=insert",
+		"-f", "h264",
+		"-",
+	}
+}
+
+func (p *Publisher) Start(ctx context.Context) error {
+	fps := int(math.Round(p.cam.GetDetails().Infos.FPS))
+	if fps <= 0 {
+		fps = DEFAULT_FPS
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	p.cancel = cancel
+
+	cmd := exec.CommandContext(ctx, p.config.FFmpegPath, p.args(fps)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		cancel()
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("error starting ffmpeg: %v", err)
+	}
+
+	go p.feed(ctx, stdin)
+
+	go func() {
+		defer close(p.done)
+		defer cancel()
+
+		if err := p.publish(stdout, fps); err != nil && ctx.Err() == nil {
+			p.logger.Error("Error publishing WebRTC stream of camera %s: %v", p.cam.GetDetails().ID, err)
+		}
+		if err := cmd.Wait(); err != nil && ctx.Err() == nil {
+			p.logger.Error("ffmpeg exited for camera %s: %v %s", p.cam.GetDetails().ID, err, strings.TrimSpace(stderr.String()))
+		}
+	}()
+
+	return nil
+}
+
+func (p *Publisher) feed(ctx context.Context, stdin io.WriteCloser) {
+	defer stdin.Close()
+
+	sub := p.cam.Subscribe()
+	defer sub.Close()
+
+	for ctx.Err() == nil {
+		img, err := sub.Capture()
+		if err != nil {
+			return
+		}
+		if _, err := stdin.Write(img); err != nil {
+			return
+		}
+	}
+}
+
+// publish groups the NAL units read from ffmpeg into access units and writes
+// each of them as a single sample, timed by the wall clock.
+func (p *Publisher) publish(stdout io.Reader, fps int) error {
+	reader, err := h264reader.NewReader(stdout)
+	if err != nil {
+		return err
+	}
+
+	var accessUnit []byte
+	last := time.Now().Add(-time.Second / time.Duration(fps))
+
+	for {
+		nal, err := reader.NextNAL()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if nal.UnitType != h264reader.NalUnitTypeAUD {
+			accessUnit = append(accessUnit, annexBStartCode...)
+			accessUnit = append(accessUnit, nal.Data...)
+			continue
+		}
+		if len(accessUnit) == 0 {
+			continue
+		}
+
+		now := time.Now()
+		if err := p.track.WriteSample(media.Sample{Data: accessUnit, Duration: now.Sub(last)}); err != nil {
+			return err
+		}
+		accessUnit = nil
+		last = now
+	}
+}
+
+func (p *Publisher) Track() pion.TrackLocal {
+	return p.track
+}
+
+func (p *Publisher) Camera() camera.CameraService {
+	return p.cam
+}
+
+func (p *Publisher) Done() <-chan struct{} {
+	return p.done
+}
+
+// Close stops ffmpeg, the peers using the track stop receiving frames.
+func (p *Publisher) Close() {
+	if p.cancel != nil {
+		p.cancel()
+		<-p.done
+	}
+}
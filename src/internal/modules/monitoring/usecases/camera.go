@@ -3,11 +3,25 @@ package monitoring_use_cases
 import "monitoring-system/src/pkg/logger"
 
 type MonitoringUseCases struct {
-	CameraInfoUseCase CameraInfoUseCase
+	CameraInfoUseCase       CameraInfoUseCase
+	CameraManagementUseCase CameraManagementUseCase
+	StorageInfoUseCase      StorageInfoUseCase
+	RecordingsUseCase       RecordingsUseCase
+	MotionEventsUseCase     MotionEventsUseCase
+	MotionZonesUseCase      MotionZonesUseCase
+	HLSUseCase              HLSUseCase
+	WebRTCUseCase           WebRTCUseCase
 }
 
-func NewMonitoringUseCases(logger logger.Logger, cm CameraManager) *MonitoringUseCases {
+func NewMonitoringUseCases(logger logger.Logger, cm CameraManager, rm RetentionManager, recordings RecordingsUseCase, mm MotionManager, hls HLSManager, webrtc WebRTCManager) *MonitoringUseCases {
 	return &MonitoringUseCases{
-		CameraInfoUseCase: NewCameraInfoUseCase(cm, logger),
+		CameraInfoUseCase:       NewCameraInfoUseCase(cm, logger),
+		CameraManagementUseCase: cm,
+		StorageInfoUseCase:      rm,
+		RecordingsUseCase:       recordings,
+		MotionEventsUseCase:     mm,
+		MotionZonesUseCase:      NewMotionZonesUseCase(mm, cm),
+		HLSUseCase:              hls,
+		WebRTCUseCase:           webrtc,
 	}
 }
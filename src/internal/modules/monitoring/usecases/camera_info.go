@@ -1,13 +1,21 @@
 package monitoring_use_cases
 
 import (
+	"context"
+	"errors"
 	"monitoring-system/src/internal/modules/monitoring/domain/camera"
 	"monitoring-system/src/pkg/app_error"
 	"monitoring-system/src/pkg/logger"
+	"time"
 )
 
+// Snapshots wait for the next frame, a stalled camera must not hang the caller
+const SNAPSHOT_TIMEOUT = 5 * time.Second
+
 type CameraInfoUseCase interface {
 	GetCameraDetails() ([]camera.CameraDetails, error)
+	GetSnapshot(ctx context.Context, id string, opts camera.SnapshotOptions) ([]byte, error)
+	Subscribe(id string) (camera.FrameSubscription, error)
 }
 
 type cameraInfoUseCase struct {
@@ -23,13 +31,7 @@ func NewCameraInfoUseCase(cameraManager CameraManager, logger logger.Logger) Cam
 }
 
 func (uc *cameraInfoUseCase) GetCameraDetails() ([]camera.CameraDetails, error) {
-	cameras := uc.cameraManager.GetCameras()
-	var cameraDetails []camera.CameraDetails
-
-	for _, cam := range cameras {
-		details := cam.GetDetails()
-		cameraDetails = append(cameraDetails, details)
-	}
+	cameraDetails := uc.cameraManager.GetCameraDetails()
 
 	if len(cameraDetails) == 0 {
 		uc.logger.Warning("No cameras detected")
@@ -38,3 +40,31 @@ func (uc *cameraInfoUseCase) GetCameraDetails() ([]camera.CameraDetails, error)
 
 	return cameraDetails, nil
 }
+
+func (uc *cameraInfoUseCase) GetSnapshot(ctx context.Context, id string, opts camera.SnapshotOptions) ([]byte, error) {
+	cam, ok := uc.cameraManager.GetCameras()[id]
+	if !ok {
+		return nil, app_error.NewApiError(404, "Camera not found", id)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, SNAPSHOT_TIMEOUT)
+	defer cancel()
+
+	img, err := cam.Snapshot(ctx, opts)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, app_error.NewApiError(504, "Camera did not deliver a frame in time", id)
+	}
+	if err != nil {
+		uc.logger.Error("Error taking snapshot of camera %s: %v", id, err)
+		return nil, app_error.NewApiError(503, "Camera unavailable", err.Error())
+	}
+	return img, nil
+}
+
+func (uc *cameraInfoUseCase) Subscribe(id string) (camera.FrameSubscription, error) {
+	cam, ok := uc.cameraManager.GetCameras()[id]
+	if !ok {
+		return nil, app_error.NewApiError(404, "Camera not found", id)
+	}
+	return cam.Subscribe(), nil
+}
@@ -0,0 +1,327 @@
+package monitoring_use_cases
+
+import (
+	"context"
+	"fmt"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	camera_infra "monitoring-system/src/internal/modules/monitoring/infra/camera"
+	"monitoring-system/src/pkg/app_error"
+	"net/url"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MAX_MOTION_KERNEL_SIZE = 31
+
+// details must run inside execute, names set through the API take
+// precedence over the ones reported by the camera.
+func (cm *cameraManager) details(id string) camera.CameraDetails {
+	var details camera.CameraDetails
+	if cam, running := cm.cameras[id]; running {
+		details = cam.GetDetails()
+	} else if source, ok := cm.sources[id]; ok {
+		details = source.details
+	}
+	details.ID = id
+
+	if definition, ok := cm.definitions[id]; ok && definition.Name != "" {
+		details.Name = definition.Name
+	}
+	return details
+}
+
+// definition must run inside execute, it returns the registry entry of a
+// camera, or the one pending registration for cameras not connected yet.
+func (cm *cameraManager) definition(id string) (camera.Definition, bool) {
+	if definition, ok := cm.definitions[id]; ok {
+		return definition, definition.Enabled
+	}
+
+	source, ok := cm.sources[id]
+	if !ok {
+		return camera.Definition{}, false
+	}
+
+	definition := source.definition
+	definition.Enabled = true
+	definition.CreatedAt = time.Now()
+	return definition, true
+}
+
+func (cm *cameraManager) save(ctx context.Context, definition camera.Definition) error {
+	if err := cm.repository.Save(ctx, definition); err != nil {
+		return err
+	}
+	cm.definitions[definition.ID] = definition
+	return nil
+}
+
+// unregister must run inside execute, it stops a running camera without
+// scheduling a reconnection.
+func (cm *cameraManager) unregister(id string) {
+	cam, ok := cm.cameras[id]
+	if !ok {
+		return
+	}
+	delete(cm.cameras, id)
+	cm.recorder.Stop(id)
+	cm.motion.Stop(id)
+	if err := cam.Close(); err != nil {
+		cm.logger.Error("Error stopping camera %s: %v", id, err)
+	}
+}
+
+// reconnect must run inside execute, it restarts a camera right away,
+// dropping any pending backoff.
+func (cm *cameraManager) reconnect(definition camera.Definition) {
+	cm.unregister(definition.ID)
+	if source, ok := cm.sources[definition.ID]; ok {
+		source.attempts = 0
+		source.nextRetry = time.Time{}
+		source.ended = false
+	}
+
+	if err := cm.connect(definition); err != nil {
+		cm.logger.Warning("Error connecting camera %s: %v", definition.ID, err)
+	}
+}
+
+func validateStreamURL(source string) error {
+	u, err := url.Parse(source)
+	if err != nil || u.Host == "" {
+		return app_error.NewApiError(400, "Invalid stream URL", source)
+	}
+
+	switch u.Scheme {
+	case "rtsp", "rtsps", "http", "https":
+		return nil
+	default:
+		return app_error.NewApiError(400, "Unsupported stream scheme", "supported schemes are rtsp, rtsps, http and https")
+	}
+}
+
+// validateDefinition checks the source and settings of a definition and
+// normalizes device indexes to stable device paths.
+func validateDefinition(definition *camera.Definition) error {
+	if definition.Type == camera.SourceDevice {
+		definition.Source = stableDeviceSource(definition.Source)
+	}
+
+	if _, err := definition.DeviceID(); err != nil {
+		return app_error.NewApiError(400, "Invalid camera source", err.Error())
+	}
+	switch definition.Type {
+	case camera.SourceStream:
+		if err := validateStreamURL(definition.Source); err != nil {
+			return err
+		}
+	case camera.SourceImages:
+		if info, err := os.Stat(definition.Source); err != nil || !info.IsDir() {
+			return app_error.NewApiError(400, "Invalid images directory", definition.Source)
+		}
+	case camera.SourceFile:
+		if info, err := os.Stat(definition.Source); err != nil || !info.Mode().IsRegular() {
+			return app_error.NewApiError(400, "Invalid video file", definition.Source)
+		}
+	case camera.SourceVirtual:
+		if camera_infra.IsPattern(definition.Source) {
+			break
+		}
+		if info, err := os.Stat(definition.Source); err != nil || !info.Mode().IsRegular() {
+			return app_error.NewApiError(400, "Invalid virtual camera source", "source must be bars, clock or a video file")
+		}
+	}
+
+	if definition.Settings.RecordingMode != "" {
+		if _, err := recording.ParseMode(definition.Settings.RecordingMode); err != nil {
+			return app_error.NewApiError(400, "Invalid recording mode", err.Error())
+		}
+	}
+	settings := definition.Settings
+	if settings.JPEGQuality < 0 || settings.JPEGQuality > 100 {
+		return app_error.NewApiError(400, "Invalid JPEG quality", "jpeg_quality must be between 1 and 100")
+	}
+	if settings.Width < 0 || settings.Height < 0 || settings.FPS < 0 || settings.MinArea < 0 {
+		return app_error.NewApiError(400, "Invalid camera settings", "width, height, fps and min_area must be positive")
+	}
+	if settings.Codec != "" && len(settings.Codec) != 4 {
+		return app_error.NewApiError(400, "Invalid codec", "codec must be a FOURCC code such as MJPG")
+	}
+	if settings.Motion != nil {
+		return validateMotionSettings(*settings.Motion)
+	}
+	return nil
+}
+
+func validateMotionSettings(settings motion.Settings) error {
+	switch settings.Algorithm {
+	case "", motion.AlgorithmMOG2, motion.AlgorithmKNN, motion.AlgorithmDiff:
+	default:
+		return app_error.NewApiError(400, "Invalid motion algorithm", "algorithm must be mog2, knn or diff")
+	}
+	if settings.History < 0 || settings.VarThreshold < 0 || settings.MinFrames < 0 {
+		return app_error.NewApiError(400, "Invalid motion settings", "history, var_threshold and min_frames must be positive")
+	}
+	if settings.Threshold < 0 || settings.Threshold > 254 {
+		return app_error.NewApiError(400, "Invalid motion threshold", "threshold must be between 1 and 254")
+	}
+	if settings.KernelSize < 0 || settings.KernelSize > MAX_MOTION_KERNEL_SIZE {
+		return app_error.NewApiError(400, "Invalid motion kernel size", fmt.Sprintf("kernel_size must be between 1 and %d", MAX_MOTION_KERNEL_SIZE))
+	}
+	return nil
+}
+
+func (cm *cameraManager) ListCameras(ctx context.Context) ([]camera.Definition, error) {
+	definitions := []camera.Definition{}
+	err := cm.execute(func() error {
+		for _, definition := range cm.definitions {
+			definitions = append(definitions, definition)
+		}
+		return nil
+	})
+
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].CreatedAt.Before(definitions[j].CreatedAt)
+	})
+	return definitions, err
+}
+
+func (cm *cameraManager) AddCamera(ctx context.Context, definition camera.Definition) (camera.CameraDetails, error) {
+	if err := validateDefinition(&definition); err != nil {
+		return camera.CameraDetails{}, err
+	}
+
+	var details camera.CameraDetails
+	err := cm.execute(func() error {
+		definition.ID = uuid.New().String()
+		definition.CreatedAt = time.Now()
+
+		// Adding a removed camera again enables it under its previous ID
+		if id, ok := cm.lookup(definition); ok {
+			existing, enabled := cm.definition(id)
+			if enabled {
+				return app_error.NewApiError(409, "Camera already exists", id)
+			}
+			definition.ID = id
+			definition.CreatedAt = existing.CreatedAt
+		}
+
+		definition.Enabled = true
+		if definition.Name == "" {
+			definition.Name = defaultName(definition)
+		}
+		if err := cm.save(ctx, definition); err != nil {
+			return err
+		}
+
+		// A camera added by hand is retried right away, whatever it did before
+		delete(cm.sources, definition.ID)
+		if err := cm.connect(definition); err != nil {
+			cm.logger.Warning("Camera %s added but not connected: %v", definition.ID, err)
+		}
+
+		details = cm.details(definition.ID)
+		return nil
+	})
+	return details, err
+}
+
+func (cm *cameraManager) UpdateCamera(ctx context.Context, id string, update camera.Update) (camera.CameraDetails, error) {
+	var details camera.CameraDetails
+	err := cm.execute(func() error {
+		definition, known := cm.definition(id)
+		if _, stored := cm.definitions[id]; !known && !stored {
+			return app_error.NewApiError(404, "Camera not found", id)
+		}
+
+		previous := definition
+		if update.Name != nil {
+			definition.Name = *update.Name
+		}
+		if update.Source != nil {
+			definition.Source = *update.Source
+		}
+		if update.Settings != nil {
+			definition.Settings = *update.Settings
+		}
+		if update.Enabled != nil {
+			definition.Enabled = *update.Enabled
+		}
+
+		if err := validateDefinition(&definition); err != nil {
+			return err
+		}
+		if definition.Source != previous.Source {
+			if other, ok := cm.lookup(definition); ok && other != id {
+				return app_error.NewApiError(409, "Camera already exists", other)
+			}
+		}
+
+		if err := cm.save(ctx, definition); err != nil {
+			return err
+		}
+		if source, ok := cm.sources[id]; ok {
+			source.definition = definition
+			source.details.Name = definition.Name
+		}
+
+		switch {
+		case !definition.Enabled:
+			cm.unregister(id)
+			delete(cm.sources, id)
+		case !previous.Enabled || definition.Source != previous.Source || !reflect.DeepEqual(definition.Settings, previous.Settings):
+			cm.logger.Info("Applying new definition of camera %s", id)
+			cm.reconnect(definition)
+		}
+
+		details = cm.details(id)
+		return nil
+	})
+	return details, err
+}
+
+func (cm *cameraManager) RemoveCamera(ctx context.Context, id string) error {
+	return cm.execute(func() error {
+		definition, enabled := cm.definition(id)
+		if !enabled {
+			return app_error.NewApiError(404, "Camera not found", id)
+		}
+
+		definition.Enabled = false
+		if err := cm.save(ctx, definition); err != nil {
+			return err
+		}
+
+		cm.unregister(id)
+		delete(cm.sources, id)
+		cm.logger.Info("Camera %s removed", id)
+		return nil
+	})
+}
+
+func (cm *cameraManager) RestartCamera(ctx context.Context, id string) (camera.CameraDetails, error) {
+	var details camera.CameraDetails
+	err := cm.execute(func() error {
+		definition, enabled := cm.definition(id)
+		if !enabled {
+			return app_error.NewApiError(404, "Camera not found", id)
+		}
+
+		cm.logger.Info("Restarting camera %s", id)
+		cm.reconnect(definition)
+
+		details = cm.details(id)
+		return nil
+	})
+	return details, err
+}
+
+func (cm *cameraManager) RenameCamera(ctx context.Context, id, name string) (camera.CameraDetails, error) {
+	return cm.UpdateCamera(ctx, id, camera.Update{Name: &name})
+}
@@ -2,49 +2,166 @@ package monitoring_use_cases
 
 import (
 	"context"
-	"crypto/md5"
 	"errors"
 	"fmt"
 	"monitoring-system/src/config"
 	"monitoring-system/src/internal/modules/monitoring/domain/camera"
 	camera_infra "monitoring-system/src/internal/modules/monitoring/infra/camera"
 	"monitoring-system/src/pkg/logger"
+	"net/url"
 	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 const DARWIN_MAX_CAMERAS = 3
 
+const DEFAULT_JPEG_QUALITY = 75
+
+// Used when the configuration leaves the reconnection delays unset
+const (
+	DEFAULT_RECONNECT_INTERVAL    = 5 * time.Second
+	DEFAULT_RECONNECT_MAX_BACKOFF = 5 * time.Minute
+)
+
+// Sources failing this many consecutive attempts are reported as failed,
+// they are still retried with the maximum backoff.
+const FAILED_AFTER_ATTEMPTS = 5
+
+// Stable names of the video devices, they do not change when devices are
+// plugged in a different order.
+const LINUX_DEVICES_BY_ID = "/dev/v4l/by-id"
+
+var (
+	ErrCameraManagerClosed = errors.New("camera manager closed")
+	ErrCameraAlreadyExists = errors.New("camera already exists")
+	errCameraBackoff       = errors.New("camera is waiting to reconnect")
+	errCameraDisabled      = errors.New("camera is disabled")
+	errCameraEnded         = errors.New("camera reached the end of its source")
+	errCameraUnusable      = errors.New("device never opened")
+)
+
+type CameraManagementUseCase interface {
+	ListCameras(ctx context.Context) ([]camera.Definition, error)
+	AddCamera(ctx context.Context, definition camera.Definition) (camera.CameraDetails, error)
+	UpdateCamera(ctx context.Context, id string, update camera.Update) (camera.CameraDetails, error)
+	RemoveCamera(ctx context.Context, id string) error
+	RestartCamera(ctx context.Context, id string) (camera.CameraDetails, error)
+	RenameCamera(ctx context.Context, id, name string) (camera.CameraDetails, error)
+}
+
 type CameraManager interface {
+	CameraManagementUseCase
 	CheckSystemCameras() error
+	HasCamera(id string) bool
 	GetCameras() map[string]camera.CameraService
+	GetCameraDetails() []camera.CameraDetails
 	Close() error
 }
 
+// CameraFactory opens the camera of a definition, NewCamera in production.
+// Tests inject fake cameras to run the manager without any device.
+type CameraFactory func(ctx context.Context, definition camera.Definition, logger logger.Logger, config *config.CameraConfig) (camera.CameraService, error)
+
+// NewCamera opens the frame source of definition and wraps it in a camera.
+func NewCamera(ctx context.Context, definition camera.Definition, logger logger.Logger, config *config.CameraConfig) (camera.CameraService, error) {
+	source, err := camera_infra.NewFrameSource(definition, config)
+	if err != nil {
+		return nil, err
+	}
+
+	webcam := camera_infra.NewCameraService(ctx, definition.ID, definition.Name, source, logger, config)
+	if webcam == nil {
+		return nil, fmt.Errorf("invalid source %s", definition.Source)
+	}
+	return webcam, nil
+}
+
 type command struct {
 	action func() error
 	result chan error
 }
 
+// cameraSource remembers a camera while it is not running, so the API can
+// report it as reconnecting or failed instead of dropping it. Its definition
+// is only persisted once the camera worked, or when added through the API.
+type cameraSource struct {
+	definition camera.Definition
+	attempts   int
+	nextRetry  time.Time
+	connected  bool
+	// ended is set when a file played once is over, it is only started
+	// again through the API
+	ended bool
+	// unusable is set on device nodes that failed before ever opening, such
+	// as metadata nodes, they are only tried again once plugged again
+	unusable bool
+	details  camera.CameraDetails
+}
+
+func (s *cameraSource) fail(state camera.State, err error, nextRetry time.Time) {
+	if s.attempts >= FAILED_AFTER_ATTEMPTS || !s.connected {
+		state = camera.StateFailed
+	}
+	s.nextRetry = nextRetry
+	s.details.Status.Transition(state, err)
+	s.details.Status.RetryCount = s.attempts
+	s.details.Status.NextRetryAt = &nextRetry
+}
+
+// visible reports whether an offline source is worth listing: configured
+// sources always are, devices only once they worked (e.g. not metadata nodes).
+func (s *cameraSource) visible() bool {
+	return s.connected || s.definition.Type != camera.SourceDevice
+}
+
 type cameraManager struct {
 	cameras     map[string]camera.CameraService
+	sources     map[string]*cameraSource
 	logger      logger.Logger
 	ctx         context.Context
 	cancel      context.CancelFunc
 	commandChan chan command
+	closed      chan struct{}
 	config      *config.CameraConfig
+	recorder    Recorder
+	motion      MotionManager
+	repository  camera.Repository
+	definitions map[string]camera.Definition
+	newCamera   CameraFactory
 }
 
-func NewCameraManager(ctx context.Context, logger logger.Logger, config *config.CameraConfig) (CameraManager, error) {
+func NewCameraManager(ctx context.Context, logger logger.Logger, config *config.CameraConfig, recorder Recorder, motion MotionManager, repository camera.Repository, newCamera CameraFactory) (CameraManager, error) {
+	definitions, err := repository.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	ctx, cancel := context.WithCancel(ctx)
 	cm := &cameraManager{
 		cameras:     make(map[string]camera.CameraService),
+		sources:     make(map[string]*cameraSource),
+		definitions: make(map[string]camera.Definition),
+		repository:  repository,
 		logger:      logger,
 		ctx:         ctx,
 		cancel:      cancel,
 		commandChan: make(chan command),
+		closed:      make(chan struct{}),
 		config:      config,
+		recorder:    recorder,
+		motion:      motion,
+		newCamera:   newCamera,
+	}
+
+	for _, definition := range definitions {
+		cm.definitions[definition.ID] = definition
 	}
 
 	go cm.run()
@@ -53,52 +170,306 @@ func NewCameraManager(ctx context.Context, logger logger.Logger, config *config.
 }
 
 func (cm *cameraManager) run() {
-	for cmd := range cm.commandChan {
-		err := cmd.action()
-		cmd.result <- err
-		close(cmd.result)
+	for {
+		select {
+		case <-cm.closed:
+			cm.logger.Warning("Camera manager command loop closed")
+			return
+		case cmd := <-cm.commandChan:
+			err := cmd.action()
+			cmd.result <- err
+			close(cmd.result)
+		}
 	}
-	cm.logger.Warning("Camera manager command channel closed")
 }
 
 func (cm *cameraManager) execute(action func() error) error {
-	cmd := command{action: action, result: make(chan error)}
-	cm.commandChan <- cmd
+	cmd := command{action: action, result: make(chan error, 1)}
+	select {
+	case <-cm.closed:
+		return ErrCameraManagerClosed
+	case cm.commandChan <- cmd:
+	}
 	return <-cmd.result
 }
 
-func (cm *cameraManager) newWebcam(deviceId interface{}) error {
-	var id string
-	switch v := deviceId.(type) {
-	case int:
-		id = fmt.Sprintf("%d", v)
-	case string:
-		id = fmt.Sprintf("%x", md5.Sum([]byte(v)))
-	default:
-		cm.logger.Error("deviceID is not of type int or string")
-		return nil
+// backoff returns the delay before the next connection attempt, doubling on
+// every failed attempt up to ReconnectMaxBackoff.
+func (cm *cameraManager) backoff(attempts int) time.Duration {
+	delay := cm.config.ReconnectInterval
+	if delay <= 0 {
+		delay = DEFAULT_RECONNECT_INTERVAL
+	}
+	maxDelay := cm.config.ReconnectMaxBackoff
+	if maxDelay <= 0 {
+		maxDelay = DEFAULT_RECONNECT_MAX_BACKOFF
+	}
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+func sameSource(a, b camera.Definition) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	if a.Source == b.Source {
+		return true
+	}
+	if a.Type != camera.SourceDevice {
+		return false
+	}
+
+	// An index and a device path may point to the same device
+	deviceA, errA := a.DeviceID()
+	deviceB, errB := b.DeviceID()
+	return errA == nil && errB == nil && deviceA == deviceB
+}
+
+// lookup must run inside execute, it returns the ID already assigned to the
+// source of definition, preferring exact matches over resolved devices.
+func (cm *cameraManager) lookup(definition camera.Definition) (string, bool) {
+	for _, exact := range []bool{true, false} {
+		match := func(other camera.Definition) bool {
+			if exact {
+				return other.Type == definition.Type && other.Source == definition.Source
+			}
+			return sameSource(other, definition)
+		}
+
+		for id, stored := range cm.definitions {
+			if match(stored) {
+				return id, true
+			}
+		}
+		for id, source := range cm.sources {
+			if match(source.definition) {
+				return id, true
+			}
+		}
+	}
+	return "", false
+}
+
+func defaultName(definition camera.Definition) string {
+	switch definition.Type {
+	case camera.SourceDevice:
+		if index, err := strconv.Atoi(definition.Source); err == nil {
+			return fmt.Sprintf("Camera %d", index)
+		}
+		// e.g. usb-Logitech_HD_Webcam_C270_1234-video-index0
+		name := filepath.Base(definition.Source)
+		name = strings.TrimPrefix(name, "usb-")
+		if i := strings.LastIndex(name, "-video-index"); i > 0 {
+			name = name[:i]
+		}
+		return strings.ReplaceAll(name, "_", " ")
+	case camera.SourceStream:
+		if u, err := url.Parse(definition.Source); err == nil && u.Hostname() != "" {
+			return fmt.Sprintf("Camera %s", u.Hostname())
+		}
+	case camera.SourceImages:
+		return fmt.Sprintf("Images %s", filepath.Base(definition.Source))
+	case camera.SourceFile:
+		return fmt.Sprintf("File %s", filepath.Base(definition.Source))
+	case camera.SourceVirtual:
+		switch definition.Source {
+		case camera_infra.PATTERN_CLOCK:
+			return "Virtual clock"
+		case "", camera_infra.PATTERN_BARS:
+			return "Virtual test pattern"
+		}
+		return fmt.Sprintf("Virtual %s", filepath.Base(definition.Source))
+	}
+	return "Camera"
+}
+
+// connect opens a camera unless it is already running, disabled or still
+// backing off from a previous failure. Sources get their registry ID on first
+// sight, so a camera that disconnects is restored under the same ID.
+func (cm *cameraManager) connect(definition camera.Definition) error {
+	id := definition.ID
+	if id == "" {
+		if existing, ok := cm.lookup(definition); ok {
+			id = existing
+		} else {
+			id = uuid.New().String()
+		}
 	}
 
 	if _, exists := cm.cameras[id]; exists {
-		return errors.New("camera already exists")
+		return ErrCameraAlreadyExists
+	}
+
+	stored, registered := cm.definitions[id]
+	if registered {
+		if !stored.Enabled {
+			return errCameraDisabled
+		}
+		definition = stored
+	}
+	definition.ID = id
+	if definition.Name == "" {
+		definition.Name = defaultName(definition)
+	}
+
+	source, ok := cm.sources[id]
+	if !ok {
+		source = &cameraSource{
+			details: camera.CameraDetails{
+				ID:     id,
+				Status: camera.Status{State: camera.StateStarting, Since: time.Now()},
+			},
+		}
+		cm.sources[id] = source
+	}
+	source.definition = definition
+	source.details.Name = definition.Name
+
+	if source.ended {
+		return errCameraEnded
+	}
+	if source.unusable {
+		return errCameraUnusable
+	}
+	if time.Now().Before(source.nextRetry) {
+		return errCameraBackoff
 	}
 
-	webcam := camera_infra.NewCameraService(cm.ctx, id, deviceId, cm.logger, cm.config)
+	if err := cm.newWebcam(definition); err != nil {
+		if !registered && !source.connected && isDeviceNode(definition) {
+			source.unusable = true
+			return err
+		}
+		source.attempts++
+		source.fail(camera.StateReconnecting, err, time.Now().Add(cm.backoff(source.attempts)))
+		return err
+	}
+
+	if source.attempts > 0 {
+		cm.logger.Info("Camera %s reconnected after %d attempts", id, source.attempts)
+	}
+	source.attempts = 0
+	source.nextRetry = time.Time{}
+	source.connected = true
+
+	if !registered {
+		definition.Enabled = true
+		definition.CreatedAt = time.Now()
+		if err := cm.save(cm.ctx, definition); err != nil {
+			cm.logger.Error("Error registering camera %s: %v", id, err)
+		}
+	}
+	return nil
+}
+
+// cameraConfig merges the overrides of a camera over the global
+// configuration, from the least to the most specific: the camera entry of the
+// config, its stream entry and the settings stored in the registry.
+func (cm *cameraManager) cameraConfig(definition camera.Definition) *config.CameraConfig {
+	base := *cm.config
+	if definition.Type == camera.SourceFile {
+		// Recordings are replayed at their own size and frame rate unless overridden
+		base.Width, base.Height, base.FPS = 0, 0, 0
+	}
+	cfg := base.ForCamera(definition.ID)
 
-	err := webcam.Start()
+	if definition.Type != camera.SourceDevice {
+		for _, stream := range cm.config.Stream {
+			if streamType(stream) == definition.Type && stream.URL == definition.Source {
+				cfg = cfg.WithOverride(stream.CameraOverride)
+				break
+			}
+		}
+	}
+
+	settings := definition.Settings
+	var motionOverride config.MotionConfig
+	if settings.Motion != nil {
+		motionOverride = config.MotionConfig{
+			Algorithm:     string(settings.Motion.Algorithm),
+			History:       settings.Motion.History,
+			VarThreshold:  settings.Motion.VarThreshold,
+			DetectShadows: settings.Motion.DetectShadows,
+			Threshold:     settings.Motion.Threshold,
+			KernelSize:    settings.Motion.KernelSize,
+			MinFrames:     settings.Motion.MinFrames,
+		}
+	}
+	cfg = cfg.WithOverride(config.CameraOverride{
+		Width:         settings.Width,
+		Height:        settings.Height,
+		FPS:           settings.FPS,
+		Codec:         settings.Codec,
+		JPEGQuality:   settings.JPEGQuality,
+		MinArea:       settings.MinArea,
+		RecordingMode: settings.RecordingMode,
+		Overlay:       settings.Overlay,
+		Loop:          settings.Loop,
+		Motion:        motionOverride,
+	})
+
+	if cfg.JPEGQuality <= 0 || cfg.JPEGQuality > 100 {
+		cfg.JPEGQuality = DEFAULT_JPEG_QUALITY
+	}
+	return &cfg
+}
+
+func (cm *cameraManager) newWebcam(definition camera.Definition) error {
+	id := definition.ID
+	cfg := cm.cameraConfig(definition)
+	webcam, err := cm.newCamera(cm.ctx, definition, cm.logger, cfg)
 	if err != nil {
 		return err
 	}
 
+	err = webcam.Start()
+	if err != nil {
+		webcam.Close()
+		return err
+	}
+
 	cm.cameras[id] = webcam
 
+	// Motion first, it sets the zones motion recordings depend on
+	if err := cm.motion.Start(webcam); err != nil {
+		cm.logger.Error("Error starting motion detection for camera %s: %v", id, err)
+	}
+
+	if err := cm.recorder.Start(webcam); err != nil {
+		cm.logger.Error("Error starting recorder for camera %s: %v", id, err)
+	}
+
 	go func(id string) {
 		select {
 		case <-cm.ctx.Done():
 		case <-webcam.Done():
 			cm.execute(func() error {
-				cm.logger.Info("Camera %s disconnected", id)
+				// Cameras removed or restarted through the API are unregistered before closing
+				if current, ok := cm.cameras[id]; !ok || current != webcam {
+					return nil
+				}
+				cm.recorder.Stop(id)
+				cm.motion.Stop(id)
 				delete(cm.cameras, id)
+
+				details := webcam.GetDetails()
+				if definition.Type == camera.SourceFile && details.Status.State == camera.StateStopped {
+					cm.logger.Info("Camera %s finished playing %s", id, definition.Source)
+					if source, ok := cm.sources[id]; ok {
+						source.ended = true
+						source.details = details
+					}
+					return nil
+				}
+
+				cm.logger.Info("Camera %s disconnected", id)
+				if source, ok := cm.sources[id]; ok {
+					source.details = details
+					source.attempts = 1
+					source.fail(camera.StateReconnecting, errors.New("camera disconnected"), time.Now().Add(cm.backoff(source.attempts)))
+				}
 				return nil
 			})
 		}
@@ -107,16 +478,10 @@ func (cm *cameraManager) newWebcam(deviceId interface{}) error {
 	return nil
 }
 
-func getDeviceID(deviceName string) int {
-	var id int
-	fmt.Sscanf(deviceName, "video%d", &id)
-	return id
-}
-
 func (cm *cameraManager) checkMacCameras() error {
 	return cm.execute(func() error {
 		for i := 0; i < DARWIN_MAX_CAMERAS; i++ {
-			err := cm.newWebcam(i)
+			err := cm.connect(camera.Definition{Type: camera.SourceDevice, Source: strconv.Itoa(i)})
 			if err != nil {
 				continue
 			}
@@ -126,6 +491,38 @@ func (cm *cameraManager) checkMacCameras() error {
 	})
 }
 
+// linuxDeviceLinks maps /dev/videoN to its stable path, when udev provides one.
+func linuxDeviceLinks() map[string]string {
+	links := make(map[string]string)
+
+	entries, err := os.ReadDir(LINUX_DEVICES_BY_ID)
+	if err != nil {
+		return links
+	}
+	for _, entry := range entries {
+		link := filepath.Join(LINUX_DEVICES_BY_ID, entry.Name())
+		if path, err := filepath.EvalSymlinks(link); err == nil {
+			links[path] = link
+		}
+	}
+	return links
+}
+
+// stableDeviceSource replaces a device index by the stable path of the
+// device, so the registry keeps following it when numbering changes.
+func stableDeviceSource(source string) string {
+	index, err := strconv.Atoi(source)
+	if err != nil || runtime.GOOS != "linux" {
+		return source
+	}
+
+	path := fmt.Sprintf("/dev/video%d", index)
+	if link, ok := linuxDeviceLinks()[path]; ok {
+		return link
+	}
+	return path
+}
+
 func (cm *cameraManager) checkLinuxCameras() error {
 	return cm.execute(func() error {
 		devices, err := os.ReadDir("/dev")
@@ -133,36 +530,103 @@ func (cm *cameraManager) checkLinuxCameras() error {
 			return err
 		}
 
+		links := linuxDeviceLinks()
+		present := make(map[string]bool)
 		for _, device := range devices {
 			deviceName := device.Name()
 			if strings.HasPrefix(deviceName, "video") {
-				deviceID := getDeviceID(deviceName)
-				err := cm.newWebcam(deviceID)
+				source := filepath.Join("/dev", deviceName)
+				if link, ok := links[source]; ok {
+					source = link
+				}
+				present[source] = true
+				err := cm.connect(camera.Definition{Type: camera.SourceDevice, Source: source})
 				if err != nil {
 					continue
 				}
 			}
 		}
+
+		// Unplugged nodes are forgotten, so they get a fresh try when plugged again
+		for id, source := range cm.sources {
+			if source.unusable && !present[source.definition.Source] {
+				delete(cm.sources, id)
+			}
+		}
 		return nil
 	})
 }
 
-func (cm *cameraManager) connectStreamCamera() error {
-	cm.logger.Info("Connecting to stream camera")
+// streamType returns the source type of a configured stream, network
+// streams unless the entry says otherwise.
+func streamType(stream config.StreamConfig) camera.SourceType {
+	if stream.Type == "" {
+		return camera.SourceStream
+	}
+	return camera.SourceType(stream.Type)
+}
 
-	for _, stream := range cm.config.Stream {
-		err := cm.newWebcam(stream.URL)
-		if err != nil {
-			cm.logger.Error("Error connecting to stream camera %s: %v", stream.URL, err)
+// configStream returns the definition of a configured stream. Streams with a
+// stream_name keep their ID when their URL is edited in the config.
+func (cm *cameraManager) configStream(stream config.StreamConfig) camera.Definition {
+	definition := camera.Definition{Type: streamType(stream), Source: stream.URL, Name: stream.StreamName}
+	if _, ok := cm.lookup(definition); ok || stream.StreamName == "" {
+		return definition
+	}
+
+	for id, stored := range cm.definitions {
+		if stored.Type != definition.Type || stored.Name != stream.StreamName {
 			continue
 		}
+		stored.Source = stream.URL
+		if err := cm.save(cm.ctx, stored); err != nil {
+			cm.logger.Error("Error updating stream of camera %s: %v", id, err)
+		}
+		if source, ok := cm.sources[id]; ok {
+			source.definition = stored
+		}
+		break
 	}
-	return nil
+	return definition
 }
 
-func (cm *cameraManager) checkSystemCameras() error {
-	cm.logger.Info("Checking system cameras")
+// connectKnownCameras connects the configured streams and every enabled
+// camera of the registry.
+func (cm *cameraManager) connectKnownCameras() error {
+	return cm.execute(func() error {
+		for _, stream := range cm.config.Stream {
+			err := cm.connect(cm.configStream(stream))
+			if err != nil {
+				if !ignoredConnectError(err) {
+					cm.logger.Error("Error connecting to stream camera %s: %v", stream.URL, err)
+				}
+				continue
+			}
+		}
 
+		for _, definition := range cm.definitions {
+			if !definition.Enabled {
+				continue
+			}
+			if err := cm.connect(definition); err != nil && !ignoredConnectError(err) {
+				cm.logger.Error("Error connecting to camera %s: %v", definition.ID, err)
+			}
+		}
+		return nil
+	})
+}
+
+func ignoredConnectError(err error) bool {
+	return errors.Is(err, ErrCameraAlreadyExists) || errors.Is(err, errCameraBackoff) || errors.Is(err, errCameraDisabled) || errors.Is(err, errCameraEnded) || errors.Is(err, errCameraUnusable)
+}
+
+// isDeviceNode reports whether definition is a /dev node found by a scan,
+// several nodes of a single camera may not be capture devices.
+func isDeviceNode(definition camera.Definition) bool {
+	return definition.Type == camera.SourceDevice && strings.HasPrefix(definition.Source, "/dev/")
+}
+
+func (cm *cameraManager) checkSystemCameras() error {
 	switch runtime.GOOS {
 	case "linux":
 		return cm.checkLinuxCameras()
@@ -174,48 +638,115 @@ func (cm *cameraManager) checkSystemCameras() error {
 }
 
 func (cm *cameraManager) CheckSystemCameras() error {
-	err := cm.checkSystemCameras()
-	if err != nil {
-		return err
+	if cm.config.CheckSystemCameras {
+		cm.logger.Info("Checking system cameras")
+		err := cm.checkSystemCameras()
+		if err != nil {
+			return err
+		}
 	}
 
-	err = cm.connectStreamCamera()
+	err := cm.connectKnownCameras()
 	if err != nil {
 		return err
 	}
 
-	// go func() { //TODO: Fix camera reconnection
-	// 	ticker := time.NewTicker(20 * time.Second)
-	// 	defer ticker.Stop()
-	// 	for {
-	// 		select {
-	// 		case <-cm.ctx.Done():
-	// 			return
-	// 		case <-ticker.C:
-	// 			if err := cm.checkSystemCameras(); err != nil {
-	// 				cm.logger.Error("Error updating camera status %v", err)
-	// 			}
-	// 		}
-	// 	}
-	// }()
+	go cm.supervise()
 
 	return nil
 }
 
+// supervise rescans the system for hot-plugged devices and retries every known
+// camera that is not running, each one respecting its own backoff.
+func (cm *cameraManager) supervise() {
+	interval := cm.config.ReconnectInterval
+	if interval <= 0 {
+		interval = DEFAULT_RECONNECT_INTERVAL
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-cm.ctx.Done():
+			return
+		case <-ticker.C:
+			if cm.config.CheckSystemCameras {
+				if err := cm.checkSystemCameras(); err != nil && !errors.Is(err, ErrCameraManagerClosed) {
+					cm.logger.Error("Error updating camera status %v", err)
+				}
+			}
+			if err := cm.connectKnownCameras(); err != nil && !errors.Is(err, ErrCameraManagerClosed) {
+				cm.logger.Error("Error reconnecting cameras %v", err)
+			}
+		}
+	}
+}
+
 func (cm *cameraManager) Close() error {
 	return cm.execute(func() error {
+		cm.recorder.Close()
+		cm.motion.Close()
 		for i, cam := range cm.cameras {
 			err := cam.Close()
 			if err != nil {
-				cm.logger.Error("Error stopping camera %d: %v\n", i, err)
+				cm.logger.Error("Error stopping camera %s: %v", i, err)
 			}
+			delete(cm.cameras, i)
+		}
+		for _, source := range cm.sources {
+			source.details.Status.Transition(camera.StateStopped, nil)
+			source.details.Status.NextRetryAt = nil
 		}
 		cm.cancel()
-		close(cm.commandChan)
+		close(cm.closed)
 		return nil
 	})
 }
 
+// GetCameraDetails lists running cameras along with the known ones that are
+// currently offline, sorted by ID.
+func (cm *cameraManager) GetCameraDetails() []camera.CameraDetails {
+	var details []camera.CameraDetails
+	cm.execute(func() error {
+		for id := range cm.cameras {
+			details = append(details, cm.details(id))
+		}
+		for id, source := range cm.sources {
+			if _, running := cm.cameras[id]; running || !source.visible() {
+				continue
+			}
+			details = append(details, cm.details(id))
+		}
+		return nil
+	})
+
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].ID < details[j].ID
+	})
+	return details
+}
+
+// HasCamera reports whether id is a known camera, running or not.
+func (cm *cameraManager) HasCamera(id string) bool {
+	var known bool
+	cm.execute(func() error {
+		_, enabled := cm.definition(id)
+		_, stored := cm.definitions[id]
+		known = enabled || stored
+		return nil
+	})
+	return known
+}
+
 func (cm *cameraManager) GetCameras() map[string]camera.CameraService {
-	return cm.cameras
+	cameras := make(map[string]camera.CameraService)
+	cm.execute(func() error {
+		for id, cam := range cm.cameras {
+			cameras[id] = cam
+		}
+		return nil
+	})
+	return cameras
 }
@@ -0,0 +1,491 @@
+package monitoring_use_cases
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/jpeg"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	camera_infra "monitoring-system/src/internal/modules/monitoring/infra/camera"
+	"monitoring-system/src/pkg/logger"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config.CameraConfig
+		attempts int
+		want     time.Duration
+	}{
+		{"first attempt", config.CameraConfig{ReconnectInterval: time.Second, ReconnectMaxBackoff: time.Minute}, 1, time.Second},
+		{"doubles", config.CameraConfig{ReconnectInterval: time.Second, ReconnectMaxBackoff: time.Minute}, 4, 8 * time.Second},
+		{"capped", config.CameraConfig{ReconnectInterval: time.Second, ReconnectMaxBackoff: time.Minute}, 20, time.Minute},
+		{"unset max still grows", config.CameraConfig{ReconnectInterval: time.Second}, 4, 8 * time.Second},
+		{"unset max is capped by default", config.CameraConfig{ReconnectInterval: time.Second}, 50, DEFAULT_RECONNECT_MAX_BACKOFF},
+		{"unset interval", config.CameraConfig{}, 1, DEFAULT_RECONNECT_INTERVAL},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cm := &cameraManager{config: &tt.config}
+			if got := cm.backoff(tt.attempts); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeCamera stands in for a device, it runs until closed or disconnected.
+type fakeCamera struct {
+	details camera.CameraDetails
+	done    chan struct{}
+	once    sync.Once
+}
+
+func (c *fakeCamera) Start() error {
+	c.details.Status.Transition(camera.StateStreaming, nil)
+	return nil
+}
+
+func (c *fakeCamera) Close() error {
+	c.once.Do(func() { close(c.done) })
+	return nil
+}
+
+// disconnect ends the camera on its own, as an unplugged device would
+func (c *fakeCamera) disconnect(state camera.State) {
+	c.details.Status.Transition(state, nil)
+	c.Close()
+}
+
+func (c *fakeCamera) RecordVideo(ctx context.Context, opts recording.Options) error { return nil }
+
+func (c *fakeCamera) DetectMotion(ctx context.Context, onDetection func(motion.Detection)) error {
+	return nil
+}
+
+func (c *fakeCamera) SetMotionZones(zones []motion.Zone) {}
+
+func (c *fakeCamera) Capture() ([]byte, error) { return nil, nil }
+
+func (c *fakeCamera) Snapshot(ctx context.Context, opts camera.SnapshotOptions) ([]byte, error) {
+	return nil, nil
+}
+
+func (c *fakeCamera) Subscribe() camera.FrameSubscription { return nil }
+
+func (c *fakeCamera) Done() <-chan struct{} { return c.done }
+
+func (c *fakeCamera) GetDetails() camera.CameraDetails { return c.details }
+
+// fakeDevices hands out fake cameras, failing the sources listed in failing.
+type fakeDevices struct {
+	mu      sync.Mutex
+	failing map[string]bool
+	opened  map[string]int
+	cameras map[string]*fakeCamera
+}
+
+func newFakeDevices() *fakeDevices {
+	return &fakeDevices{failing: map[string]bool{}, opened: map[string]int{}, cameras: map[string]*fakeCamera{}}
+}
+
+func (d *fakeDevices) open(ctx context.Context, definition camera.Definition, logger logger.Logger, config *config.CameraConfig) (camera.CameraService, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.opened[definition.Source]++
+	if d.failing[definition.Source] {
+		return nil, errors.New("device unavailable")
+	}
+	cam := &fakeCamera{
+		details: camera.CameraDetails{ID: definition.ID, Name: definition.Name},
+		done:    make(chan struct{}),
+	}
+	d.cameras[definition.ID] = cam
+	return cam, nil
+}
+
+func (d *fakeDevices) attempts(source string) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.opened[source]
+}
+
+func (d *fakeDevices) camera(id string) *fakeCamera {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.cameras[id]
+}
+
+type fakeRecorder struct {
+	mu      sync.Mutex
+	started map[string]bool
+}
+
+func (r *fakeRecorder) Start(cam camera.CameraService) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.started[cam.GetDetails().ID] = true
+	return nil
+}
+
+func (r *fakeRecorder) Stop(cameraID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.started, cameraID)
+}
+
+func (r *fakeRecorder) Close() {}
+
+func (r *fakeRecorder) recording(cameraID string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.started[cameraID]
+}
+
+// fakeMotion only implements what the manager calls
+type fakeMotion struct {
+	MotionManager
+}
+
+func (m *fakeMotion) Start(cam camera.CameraService) error { return nil }
+
+func (m *fakeMotion) Stop(cameraID string) {}
+
+func (m *fakeMotion) Close() {}
+
+type fakeRepository struct {
+	mu          sync.Mutex
+	definitions map[string]camera.Definition
+}
+
+func (r *fakeRepository) List(ctx context.Context) ([]camera.Definition, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	definitions := []camera.Definition{}
+	for _, definition := range r.definitions {
+		definitions = append(definitions, definition)
+	}
+	return definitions, nil
+}
+
+func (r *fakeRepository) Save(ctx context.Context, definition camera.Definition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.definitions[definition.ID] = definition
+	return nil
+}
+
+type managerTest struct {
+	manager  *cameraManager
+	devices  *fakeDevices
+	recorder *fakeRecorder
+}
+
+func newManagerTest(t *testing.T) *managerTest {
+	t.Helper()
+	log, err := logger.NewLogger("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	devices := newFakeDevices()
+	recorder := &fakeRecorder{started: map[string]bool{}}
+	repository := &fakeRepository{definitions: map[string]camera.Definition{}}
+	// Long enough for a failed camera to stay in backoff during a test
+	cfg := &config.CameraConfig{ReconnectInterval: time.Hour, ReconnectMaxBackoff: time.Hour}
+
+	manager, err := NewCameraManager(context.Background(), log, cfg, recorder, &fakeMotion{}, repository, devices.open)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { manager.Close() })
+	return &managerTest{manager: manager.(*cameraManager), devices: devices, recorder: recorder}
+}
+
+func (m *managerTest) connect(definition camera.Definition) error {
+	return m.manager.execute(func() error {
+		return m.manager.connect(definition)
+	})
+}
+
+func (m *managerTest) details(t *testing.T, id string) camera.CameraDetails {
+	t.Helper()
+	for _, details := range m.manager.GetCameraDetails() {
+		if details.ID == id {
+			return details
+		}
+	}
+	t.Fatalf("camera %s not listed", id)
+	return camera.CameraDetails{}
+}
+
+// waitStopped waits for the manager to notice a camera ended on its own
+func (m *managerTest) waitStopped(t *testing.T, id string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if _, running := m.manager.GetCameras()[id]; !running {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("camera %s still running", id)
+}
+
+func TestAddCameraStartsAndRegistersIt(t *testing.T) {
+	m := newManagerTest(t)
+
+	details, err := m.manager.AddCamera(context.Background(), camera.Definition{Type: camera.SourceStream, Source: "rtsp://door.local/live"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if details.Status.State != camera.StateStreaming {
+		t.Errorf("state = %s, want %s", details.Status.State, camera.StateStreaming)
+	}
+	if details.Name != "Camera door.local" {
+		t.Errorf("name = %q, want the default name", details.Name)
+	}
+	if _, running := m.manager.GetCameras()[details.ID]; !running {
+		t.Error("camera not running")
+	}
+	if !m.recorder.recording(details.ID) {
+		t.Error("recorder not started")
+	}
+	definitions, _ := m.manager.ListCameras(context.Background())
+	if len(definitions) != 1 || definitions[0].ID != details.ID || !definitions[0].Enabled {
+		t.Errorf("registry = %+v, want the enabled camera", definitions)
+	}
+
+	if _, err := m.manager.AddCamera(context.Background(), camera.Definition{Type: camera.SourceStream, Source: "rtsp://door.local/live"}); err == nil {
+		t.Error("adding the same source twice should fail")
+	}
+}
+
+func TestFailedCameraBacksOff(t *testing.T) {
+	m := newManagerTest(t)
+	source := "rtsp://garden.local/live"
+	m.devices.failing[source] = true
+
+	definition := camera.Definition{ID: "garden", Type: camera.SourceStream, Source: source}
+	if err := m.connect(definition); err == nil {
+		t.Fatal("connect should fail")
+	}
+	if err := m.connect(definition); !errors.Is(err, errCameraBackoff) {
+		t.Errorf("second connect = %v, want %v", err, errCameraBackoff)
+	}
+	if got := m.devices.attempts(source); got != 1 {
+		t.Errorf("opened %d times during the backoff, want 1", got)
+	}
+
+	details := m.details(t, "garden")
+	if details.Status.State != camera.StateFailed || details.Status.NextRetryAt == nil {
+		t.Errorf("status = %+v, want failed with a retry scheduled", details.Status)
+	}
+
+	// A restart through the API skips the backoff
+	m.devices.failing[source] = false
+	details, err := m.manager.RestartCamera(context.Background(), "garden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Status.State != camera.StateStreaming {
+		t.Errorf("state after restart = %s, want %s", details.Status.State, camera.StateStreaming)
+	}
+}
+
+func TestDisconnectedCameraIsReconnecting(t *testing.T) {
+	m := newManagerTest(t)
+	definition := camera.Definition{ID: "porch", Type: camera.SourceStream, Source: "rtsp://porch.local/live"}
+	if err := m.connect(definition); err != nil {
+		t.Fatal(err)
+	}
+
+	m.devices.camera("porch").disconnect(camera.StateFailed)
+	m.waitStopped(t, "porch")
+
+	details := m.details(t, "porch")
+	if details.Status.State != camera.StateReconnecting || details.Status.NextRetryAt == nil {
+		t.Errorf("status = %+v, want reconnecting with a retry scheduled", details.Status)
+	}
+	if m.recorder.recording("porch") {
+		t.Error("recorder still running for a disconnected camera")
+	}
+	if err := m.connect(definition); !errors.Is(err, errCameraBackoff) {
+		t.Errorf("connect = %v, want %v", err, errCameraBackoff)
+	}
+}
+
+func TestFinishedFileIsNotRestarted(t *testing.T) {
+	m := newManagerTest(t)
+	definition := camera.Definition{ID: "replay", Type: camera.SourceFile, Source: "/recordings/replay.mp4"}
+	if err := m.connect(definition); err != nil {
+		t.Fatal(err)
+	}
+
+	m.devices.camera("replay").disconnect(camera.StateStopped)
+	m.waitStopped(t, "replay")
+
+	if err := m.connect(definition); !errors.Is(err, errCameraEnded) {
+		t.Errorf("connect = %v, want %v", err, errCameraEnded)
+	}
+	if got := m.devices.attempts(definition.Source); got != 1 {
+		t.Errorf("opened %d times, want 1", got)
+	}
+}
+
+func TestDeviceNodeNeverOpenedIsNotRetried(t *testing.T) {
+	m := newManagerTest(t)
+	source := "/dev/video1"
+	m.devices.failing[source] = true
+
+	definition := camera.Definition{ID: "metadata", Type: camera.SourceDevice, Source: source}
+	if err := m.connect(definition); err == nil {
+		t.Fatal("connect should fail")
+	}
+	if err := m.connect(definition); !errors.Is(err, errCameraUnusable) {
+		t.Errorf("second connect = %v, want %v", err, errCameraUnusable)
+	}
+	for _, details := range m.manager.GetCameraDetails() {
+		if details.ID == "metadata" {
+			t.Error("device node that never opened should not be listed")
+		}
+	}
+}
+
+// segmentIndex keeps the segments the recorder indexes
+type segmentIndex struct {
+	mu       sync.Mutex
+	segments []recording.Segment
+}
+
+func (r *segmentIndex) Save(ctx context.Context, segment recording.Segment) (recording.Segment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	segment.ID = strconv.Itoa(len(r.segments) + 1)
+	r.segments = append(r.segments, segment)
+	return segment, nil
+}
+
+func (r *segmentIndex) GetByID(ctx context.Context, id string) (*recording.Segment, error) {
+	return nil, nil
+}
+
+func (r *segmentIndex) List(ctx context.Context, filter recording.Filter) (recording.SegmentPage, error) {
+	return recording.SegmentPage{}, nil
+}
+
+func (r *segmentIndex) DeleteByPath(ctx context.Context, path string) error { return nil }
+
+func (r *segmentIndex) first() (recording.Segment, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.segments) == 0 {
+		return recording.Segment{}, false
+	}
+	return r.segments[0], true
+}
+
+func TestVirtualCameraPipeline(t *testing.T) {
+	log, err := logger.NewLogger("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.CameraConfig{
+		FPS:               10,
+		Width:             320,
+		Height:            240,
+		Codec:             "MJPG",
+		JPEGQuality:       75,
+		Overlay:           true,
+		ReconnectInterval: time.Hour,
+		Recording:         config.RecordingConfig{Mode: "always", SegmentDuration: time.Second},
+	}
+	index := &segmentIndex{}
+	recorder, err := NewRecorder(context.Background(), log, cfg, t.TempDir(), index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repository := &fakeRepository{definitions: map[string]camera.Definition{}}
+	manager, err := NewCameraManager(context.Background(), log, cfg, recorder, &fakeMotion{}, repository, NewCamera)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer manager.Close()
+
+	details, err := manager.AddCamera(context.Background(), camera.Definition{Type: camera.SourceVirtual, Source: camera_infra.PATTERN_BARS})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if details.Status.State == camera.StateFailed {
+		t.Fatalf("virtual camera failed: %s", details.Status.LastError)
+	}
+	cam, running := manager.GetCameras()[details.ID]
+	if !running {
+		t.Fatal("virtual camera not running")
+	}
+
+	// A subscriber receives the frames as JPEG
+	frames := make(chan []byte, 1)
+	go func() {
+		sub := cam.Subscribe()
+		defer sub.Close()
+		frame, err := sub.Capture()
+		if err != nil {
+			t.Errorf("Capture: %v", err)
+		}
+		frames <- frame
+	}()
+	select {
+	case frame := <-frames:
+		if _, err := jpeg.DecodeConfig(bytes.NewReader(frame)); err != nil {
+			t.Errorf("subscriber frame is not a JPEG: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no frame reached the subscriber")
+	}
+
+	// A resized snapshot keeps the aspect ratio
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	snapshot, err := cam.Snapshot(ctx, camera.SnapshotOptions{Width: 160})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+	size, err := jpeg.DecodeConfig(bytes.NewReader(snapshot))
+	if err != nil {
+		t.Fatalf("snapshot is not a JPEG: %v", err)
+	}
+	if size.Width != 160 || size.Height != 120 {
+		t.Errorf("snapshot size = %dx%d, want 160x120", size.Width, size.Height)
+	}
+
+	// Continuous recording closes a segment every second
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		segment, ok := index.first()
+		if ok {
+			if segment.CameraID != details.ID || segment.Size <= 0 {
+				t.Errorf("segment = %+v, want a non empty segment of %s", segment, details.ID)
+			}
+			if info, err := os.Stat(segment.Path); err != nil || info.Size() == 0 {
+				t.Errorf("segment file %s missing or empty: %v", segment.Path, err)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no recording segment indexed")
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,206 @@
+package monitoring_use_cases
+
+import (
+	"context"
+	"monitoring-system/src/config"
+	hls_infra "monitoring-system/src/internal/modules/monitoring/infra/hls"
+	"monitoring-system/src/pkg/app_error"
+	"monitoring-system/src/pkg/logger"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const HLS_PLAYLIST = hls_infra.PLAYLIST_NAME
+
+var hlsSegmentName = regexp.MustCompile(`^segment_\d+\.ts$`)
+
+type HLSUseCase interface {
+	// Playlist starts packaging the camera if needed and returns its playlist.
+	Playlist(ctx context.Context, cameraID string) ([]byte, error)
+	// Segment returns the path of a segment listed in the camera playlist.
+	Segment(cameraID, name string) (string, error)
+}
+
+type HLSManager interface {
+	HLSUseCase
+	Close()
+}
+
+type hlsSession struct {
+	packager   *hls_infra.Packager
+	lastAccess time.Time
+}
+
+type hlsManager struct {
+	logger        logger.Logger
+	ctx           context.Context
+	cancel        context.CancelFunc
+	config        *config.HLSConfig
+	cameraManager CameraManager
+	basePath      string
+	sessions      map[string]*hlsSession
+	mu            sync.Mutex
+}
+
+func NewHLSManager(ctx context.Context, logger logger.Logger, config *config.HLSConfig, cameraManager CameraManager) HLSManager {
+	basePath := config.Path
+	if basePath == "" {
+		basePath = filepath.Join(os.TempDir(), "monitoring-system-hls")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	hm := &hlsManager{
+		logger:        logger,
+		ctx:           ctx,
+		cancel:        cancel,
+		config:        config,
+		cameraManager: cameraManager,
+		basePath:      basePath,
+		sessions:      make(map[string]*hlsSession),
+	}
+
+	go hm.reap()
+
+	return hm
+}
+
+// reap stops packaging cameras nobody requested for IdleTimeout.
+func (hm *hlsManager) reap() {
+	idle := hm.config.IdleTimeout
+	if idle <= 0 {
+		idle = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(idle / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-hm.ctx.Done():
+			return
+		case <-ticker.C:
+			hm.mu.Lock()
+			for id, session := range hm.sessions {
+				if time.Since(session.lastAccess) > idle {
+					hm.logger.Info("Stopping idle HLS stream for camera %s", id)
+					delete(hm.sessions, id)
+					go session.packager.Close()
+				}
+			}
+			hm.mu.Unlock()
+		}
+	}
+}
+
+func (hm *hlsManager) session(cameraID string) (*hlsSession, error) {
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	if session, ok := hm.sessions[cameraID]; ok {
+		select {
+		case <-session.packager.Done():
+			delete(hm.sessions, cameraID)
+		default:
+			session.lastAccess = time.Now()
+			return session, nil
+		}
+	}
+
+	cam, ok := hm.cameraManager.GetCameras()[cameraID]
+	if !ok {
+		return nil, app_error.NewApiError(404, "Camera not found", cameraID)
+	}
+
+	// A fresh directory per session, a stopping packager still cleans up its own
+	if err := os.MkdirAll(hm.basePath, 0755); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(hm.basePath, cameraID+"-")
+	if err != nil {
+		return nil, err
+	}
+
+	packager := hls_infra.NewPackager(cam, dir, hm.config, hm.logger)
+	if err := packager.Start(hm.ctx); err != nil {
+		os.RemoveAll(dir)
+		hm.logger.Error("Error starting HLS stream for camera %s: %v", cameraID, err)
+		return nil, app_error.NewApiError(503, "HLS stream unavailable", err.Error())
+	}
+	hm.logger.Info("HLS stream started for camera %s", cameraID)
+
+	session := &hlsSession{packager: packager, lastAccess: time.Now()}
+	hm.sessions[cameraID] = session
+	return session, nil
+}
+
+func (hm *hlsManager) Playlist(ctx context.Context, cameraID string) ([]byte, error) {
+	if !hm.config.Enabled {
+		return nil, app_error.NewApiError(404, "HLS is disabled")
+	}
+
+	session, err := hm.session(cameraID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The playlist only appears once ffmpeg completed the first segment
+	timeout := time.NewTimer(2*hm.config.SegmentDuration + 5*time.Second)
+	defer timeout.Stop()
+	poll := time.NewTicker(200 * time.Millisecond)
+	defer poll.Stop()
+
+	for {
+		playlist, err := os.ReadFile(session.packager.PlaylistPath())
+		if err == nil {
+			return playlist, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-session.packager.Done():
+			return nil, app_error.NewApiError(503, "HLS stream stopped", cameraID)
+		case <-timeout.C:
+			return nil, app_error.NewApiError(504, "HLS stream not ready", cameraID)
+		case <-poll.C:
+		}
+	}
+}
+
+func (hm *hlsManager) Segment(cameraID, name string) (string, error) {
+	if !hlsSegmentName.MatchString(name) {
+		return "", app_error.NewApiError(404, "Segment not found", name)
+	}
+
+	hm.mu.Lock()
+	session, ok := hm.sessions[cameraID]
+	if ok {
+		session.lastAccess = time.Now()
+	}
+	hm.mu.Unlock()
+
+	if !ok {
+		return "", app_error.NewApiError(404, "Segment not found", name)
+	}
+
+	path := filepath.Join(session.packager.Dir(), name)
+	if _, err := os.Stat(path); err != nil {
+		return "", app_error.NewApiError(404, "Segment not found", name)
+	}
+	return path, nil
+}
+
+func (hm *hlsManager) Close() {
+	hm.cancel()
+
+	hm.mu.Lock()
+	defer hm.mu.Unlock()
+
+	for id, session := range hm.sessions {
+		session.packager.Close()
+		delete(hm.sessions, id)
+	}
+}
@@ -0,0 +1,219 @@
+package monitoring_use_cases
+
+import (
+	"context"
+	"fmt"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/pkg/app_error"
+	"monitoring-system/src/pkg/logger"
+	"sync"
+	"time"
+)
+
+type MotionEventsUseCase interface {
+	ListEvents(ctx context.Context, filter motion.Filter) (motion.EventPage, error)
+}
+
+type MotionManager interface {
+	MotionEventsUseCase
+	MotionZonesUseCase
+	Start(cam camera.CameraService) error
+	Stop(cameraID string)
+	OnEvent(listener motion.Listener)
+	Close()
+}
+
+type motionSession struct {
+	cameraID   string
+	cancel     context.CancelFunc
+	event      *motion.Event
+	lastMotion time.Time
+}
+
+type motionManager struct {
+	logger     logger.Logger
+	ctx        context.Context
+	cancel     context.CancelFunc
+	config     *config.CameraConfig
+	repository motion.EventRepository
+	zones      motion.ZoneRepository
+	sessions   map[string]*motionSession
+	// Running cameras, zone changes are applied to them right away
+	cameras   map[string]camera.CameraService
+	listeners []motion.Listener
+	mu        sync.Mutex
+	zonesMu   sync.Mutex
+	wg        sync.WaitGroup
+}
+
+func NewMotionManager(ctx context.Context, logger logger.Logger, config *config.CameraConfig, repository motion.EventRepository, zones motion.ZoneRepository) MotionManager {
+	ctx, cancel := context.WithCancel(ctx)
+	return &motionManager{
+		logger:     logger,
+		ctx:        ctx,
+		cancel:     cancel,
+		config:     config,
+		repository: repository,
+		zones:      zones,
+		sessions:   make(map[string]*motionSession),
+		cameras:    make(map[string]camera.CameraService),
+	}
+}
+
+// Start applies the zones of the camera, recordings in motion mode use them
+// even when motion events are disabled, then starts detecting motion events.
+func (mm *motionManager) Start(cam camera.CameraService) error {
+	id := cam.GetDetails().ID
+
+	mm.zonesMu.Lock()
+	mm.mu.Lock()
+	mm.cameras[id] = cam
+	mm.mu.Unlock()
+	err := mm.applyZones(mm.ctx, id)
+	mm.zonesMu.Unlock()
+	if err != nil {
+		mm.logger.Error("Error loading motion zones of camera %s: %v", id, err)
+	}
+
+	if !mm.config.MotionDetection {
+		return nil
+	}
+
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if _, exists := mm.sessions[id]; exists {
+		return fmt.Errorf("motion detection already running for camera %s", id)
+	}
+
+	ctx, cancel := context.WithCancel(mm.ctx)
+	session := &motionSession{cameraID: id, cancel: cancel}
+	mm.sessions[id] = session
+
+	mm.wg.Add(1)
+	go func() {
+		defer mm.wg.Done()
+		defer mm.remove(session)
+
+		mm.logger.Info("Motion detection started for camera %s", id)
+		err := cam.DetectMotion(ctx, func(detection motion.Detection) {
+			mm.handleDetection(session, detection)
+		})
+		if err != nil {
+			mm.logger.Error("Error detecting motion on camera %s: %v", id, err)
+		}
+		mm.endEvent(session, time.Now())
+		mm.logger.Info("Motion detection stopped for camera %s", id)
+	}()
+
+	return nil
+}
+
+func (mm *motionManager) handleDetection(session *motionSession, detection motion.Detection) {
+	now := time.Now()
+
+	if !detection.Motion() {
+		if session.event != nil && now.Sub(session.lastMotion) >= mm.config.MotionEndDelay {
+			mm.endEvent(session, session.lastMotion)
+		}
+		return
+	}
+
+	session.lastMotion = now
+
+	if session.event == nil {
+		session.event = &motion.Event{
+			CameraID: session.cameraID,
+			Start:    now,
+			Score:    detection.Score,
+			Boxes:    detection.Boxes,
+		}
+		mm.save(session.event)
+		mm.emit(motion.EventStart, *session.event)
+		return
+	}
+
+	// Keep the boxes of the frame with the most motion as the event summary
+	if detection.Score > session.event.Score {
+		session.event.Score = detection.Score
+		session.event.Boxes = detection.Boxes
+	}
+}
+
+func (mm *motionManager) endEvent(session *motionSession, end time.Time) {
+	if session.event == nil {
+		return
+	}
+
+	session.event.End = &end
+	mm.save(session.event)
+	mm.emit(motion.EventEnd, *session.event)
+	session.event = nil
+}
+
+func (mm *motionManager) save(event *motion.Event) {
+	// Not bound to mm.ctx, ongoing events are closed while shutting down
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	saved, err := mm.repository.Save(ctx, *event)
+	if err != nil {
+		mm.logger.Error("Error saving motion event for camera %s: %v", event.CameraID, err)
+		return
+	}
+	event.ID = saved.ID
+}
+
+func (mm *motionManager) emit(eventType motion.EventType, event motion.Event) {
+	mm.logger.Info("Camera %s %s (score %.3f, %d boxes)", event.CameraID, eventType, event.Score, len(event.Boxes))
+
+	mm.mu.Lock()
+	listeners := append([]motion.Listener(nil), mm.listeners...)
+	mm.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(eventType, event)
+	}
+}
+
+func (mm *motionManager) OnEvent(listener motion.Listener) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+	mm.listeners = append(mm.listeners, listener)
+}
+
+func (mm *motionManager) remove(session *motionSession) {
+	mm.mu.Lock()
+	defer mm.mu.Unlock()
+
+	if current, ok := mm.sessions[session.cameraID]; ok && current == session {
+		delete(mm.sessions, session.cameraID)
+	}
+	session.cancel()
+}
+
+func (mm *motionManager) Stop(cameraID string) {
+	mm.mu.Lock()
+	delete(mm.cameras, cameraID)
+	session, ok := mm.sessions[cameraID]
+	mm.mu.Unlock()
+
+	if ok {
+		mm.remove(session)
+	}
+}
+
+func (mm *motionManager) Close() {
+	mm.cancel()
+	mm.wg.Wait()
+}
+
+func (mm *motionManager) ListEvents(ctx context.Context, filter motion.Filter) (motion.EventPage, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return motion.EventPage{}, app_error.NewApiError(400, "Invalid time window", "'to' must be after 'from'")
+	}
+
+	return mm.repository.List(ctx, filter)
+}
@@ -0,0 +1,189 @@
+package monitoring_use_cases
+
+import (
+	"context"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/pkg/app_error"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MIN_ZONE_POINTS = 3
+
+type MotionZonesUseCase interface {
+	ListZones(ctx context.Context, cameraID string) ([]motion.Zone, error)
+	AddZone(ctx context.Context, zone motion.Zone) (motion.Zone, error)
+	UpdateZone(ctx context.Context, cameraID, id string, update motion.ZoneUpdate) (motion.Zone, error)
+	RemoveZone(ctx context.Context, cameraID, id string) error
+}
+
+// cameraZones rejects the zones of unknown cameras, the zones repository
+// would otherwise keep them for cameras that never existed.
+type cameraZones struct {
+	MotionZonesUseCase
+	cameras CameraManager
+}
+
+func NewMotionZonesUseCase(zones MotionZonesUseCase, cameras CameraManager) MotionZonesUseCase {
+	return &cameraZones{MotionZonesUseCase: zones, cameras: cameras}
+}
+
+func (c *cameraZones) checkCamera(cameraID string) error {
+	if !c.cameras.HasCamera(cameraID) {
+		return app_error.NewApiError(404, "Camera not found", cameraID)
+	}
+	return nil
+}
+
+func (c *cameraZones) ListZones(ctx context.Context, cameraID string) ([]motion.Zone, error) {
+	if err := c.checkCamera(cameraID); err != nil {
+		return nil, err
+	}
+	return c.MotionZonesUseCase.ListZones(ctx, cameraID)
+}
+
+func (c *cameraZones) AddZone(ctx context.Context, zone motion.Zone) (motion.Zone, error) {
+	if err := c.checkCamera(zone.CameraID); err != nil {
+		return motion.Zone{}, err
+	}
+	return c.MotionZonesUseCase.AddZone(ctx, zone)
+}
+
+func (c *cameraZones) UpdateZone(ctx context.Context, cameraID, id string, update motion.ZoneUpdate) (motion.Zone, error) {
+	if err := c.checkCamera(cameraID); err != nil {
+		return motion.Zone{}, err
+	}
+	return c.MotionZonesUseCase.UpdateZone(ctx, cameraID, id, update)
+}
+
+func (c *cameraZones) RemoveZone(ctx context.Context, cameraID, id string) error {
+	if err := c.checkCamera(cameraID); err != nil {
+		return err
+	}
+	return c.MotionZonesUseCase.RemoveZone(ctx, cameraID, id)
+}
+
+func validateZone(zone motion.Zone) error {
+	if zone.Kind != motion.ZoneInclude && zone.Kind != motion.ZoneExclude {
+		return app_error.NewApiError(400, "Invalid zone kind", "kind must be include or exclude")
+	}
+	if len(zone.Points) < MIN_ZONE_POINTS {
+		return app_error.NewApiError(400, "Invalid zone polygon", "a zone needs at least 3 points")
+	}
+	for _, point := range zone.Points {
+		if point.X < 0 || point.X > 1 || point.Y < 0 || point.Y > 1 {
+			return app_error.NewApiError(400, "Invalid zone polygon", "points are relative to the frame size and must be between 0 and 1")
+		}
+	}
+	return nil
+}
+
+// applyZones must run with zonesMu held so cameras never end up with the
+// zones of an older change.
+func (mm *motionManager) applyZones(ctx context.Context, cameraID string) error {
+	mm.mu.Lock()
+	cam, ok := mm.cameras[cameraID]
+	mm.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	zones, err := mm.zones.List(ctx, cameraID)
+	if err != nil {
+		return err
+	}
+	cam.SetMotionZones(zones)
+	return nil
+}
+
+func (mm *motionManager) zone(ctx context.Context, cameraID, id string) (motion.Zone, error) {
+	zones, err := mm.zones.List(ctx, cameraID)
+	if err != nil {
+		return motion.Zone{}, err
+	}
+	for _, zone := range zones {
+		if zone.ID == id {
+			return zone, nil
+		}
+	}
+	return motion.Zone{}, app_error.NewApiError(404, "Motion zone not found", id)
+}
+
+func (mm *motionManager) ListZones(ctx context.Context, cameraID string) ([]motion.Zone, error) {
+	return mm.zones.List(ctx, cameraID)
+}
+
+func (mm *motionManager) AddZone(ctx context.Context, zone motion.Zone) (motion.Zone, error) {
+	if err := validateZone(zone); err != nil {
+		return motion.Zone{}, err
+	}
+
+	zone.ID = uuid.New().String()
+	zone.CreatedAt = time.Now()
+
+	mm.zonesMu.Lock()
+	defer mm.zonesMu.Unlock()
+
+	if err := mm.zones.Save(ctx, zone); err != nil {
+		return motion.Zone{}, err
+	}
+	if err := mm.applyZones(ctx, zone.CameraID); err != nil {
+		mm.logger.Error("Error applying motion zones of camera %s: %v", zone.CameraID, err)
+	}
+
+	mm.logger.Info("Motion zone %s added to camera %s", zone.ID, zone.CameraID)
+	return zone, nil
+}
+
+func (mm *motionManager) UpdateZone(ctx context.Context, cameraID, id string, update motion.ZoneUpdate) (motion.Zone, error) {
+	mm.zonesMu.Lock()
+	defer mm.zonesMu.Unlock()
+
+	zone, err := mm.zone(ctx, cameraID, id)
+	if err != nil {
+		return motion.Zone{}, err
+	}
+
+	if update.Name != nil {
+		zone.Name = *update.Name
+	}
+	if update.Kind != nil {
+		zone.Kind = *update.Kind
+	}
+	if update.Points != nil {
+		zone.Points = *update.Points
+	}
+	if update.Enabled != nil {
+		zone.Enabled = *update.Enabled
+	}
+	if err := validateZone(zone); err != nil {
+		return motion.Zone{}, err
+	}
+
+	if err := mm.zones.Save(ctx, zone); err != nil {
+		return motion.Zone{}, err
+	}
+	if err := mm.applyZones(ctx, cameraID); err != nil {
+		mm.logger.Error("Error applying motion zones of camera %s: %v", cameraID, err)
+	}
+	return zone, nil
+}
+
+func (mm *motionManager) RemoveZone(ctx context.Context, cameraID, id string) error {
+	mm.zonesMu.Lock()
+	defer mm.zonesMu.Unlock()
+
+	if _, err := mm.zone(ctx, cameraID, id); err != nil {
+		return err
+	}
+	if err := mm.zones.Delete(ctx, id); err != nil {
+		return err
+	}
+	if err := mm.applyZones(ctx, cameraID); err != nil {
+		mm.logger.Error("Error applying motion zones of camera %s: %v", cameraID, err)
+	}
+
+	mm.logger.Info("Motion zone %s removed from camera %s", id, cameraID)
+	return nil
+}
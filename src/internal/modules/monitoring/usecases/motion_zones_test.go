@@ -0,0 +1,54 @@
+package monitoring_use_cases
+
+import (
+	"context"
+	"errors"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/internal/modules/monitoring/domain/motion"
+	"monitoring-system/src/pkg/app_error"
+	"testing"
+)
+
+// savedZones records the zones reaching the motion manager
+type savedZones struct {
+	MotionZonesUseCase
+	added []motion.Zone
+}
+
+func (z *savedZones) AddZone(ctx context.Context, zone motion.Zone) (motion.Zone, error) {
+	z.added = append(z.added, zone)
+	return zone, nil
+}
+
+func (z *savedZones) ListZones(ctx context.Context, cameraID string) ([]motion.Zone, error) {
+	return z.added, nil
+}
+
+func TestZonesOfUnknownCameraAreRejected(t *testing.T) {
+	m := newManagerTest(t)
+	if err := m.connect(camera.Definition{ID: "gate", Type: camera.SourceStream, Source: "rtsp://gate.local/live"}); err != nil {
+		t.Fatal(err)
+	}
+	zones := &savedZones{}
+	uc := NewMotionZonesUseCase(zones, m.manager)
+
+	points := []motion.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}}
+	_, err := uc.AddZone(context.Background(), motion.Zone{CameraID: "missing", Kind: motion.ZoneInclude, Points: points})
+	var apiErr *app_error.ApiError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 404 {
+		t.Errorf("AddZone on an unknown camera = %v, want a 404", err)
+	}
+	if _, err := uc.ListZones(context.Background(), "missing"); !errors.As(err, &apiErr) || apiErr.StatusCode != 404 {
+		t.Errorf("ListZones on an unknown camera = %v, want a 404", err)
+	}
+	if len(zones.added) != 0 {
+		t.Errorf("zones saved for an unknown camera: %+v", zones.added)
+	}
+
+	if _, err := uc.AddZone(context.Background(), motion.Zone{CameraID: "gate", Kind: motion.ZoneInclude, Points: points}); err != nil {
+		t.Errorf("AddZone on a known camera: %v", err)
+	}
+	if len(zones.added) != 1 {
+		t.Errorf("zone of a known camera not saved")
+	}
+}
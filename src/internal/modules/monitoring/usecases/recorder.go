@@ -0,0 +1,144 @@
+package monitoring_use_cases
+
+import (
+	"context"
+	"fmt"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/camera"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	"monitoring-system/src/pkg/logger"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type Recorder interface {
+	Start(cam camera.CameraService) error
+	Stop(cameraID string)
+	Close()
+}
+
+type recordingSession struct {
+	cancel context.CancelFunc
+}
+
+type recorder struct {
+	logger     logger.Logger
+	ctx        context.Context
+	cancel     context.CancelFunc
+	config     *config.CameraConfig
+	basePath   string
+	repository recording.Repository
+	sessions   map[string]*recordingSession
+	mu         sync.Mutex
+	wg         sync.WaitGroup
+}
+
+func NewRecorder(ctx context.Context, logger logger.Logger, config *config.CameraConfig, basePath string, repository recording.Repository) (Recorder, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	return &recorder{
+		logger:     logger,
+		ctx:        ctx,
+		cancel:     cancel,
+		config:     config,
+		basePath:   basePath,
+		repository: repository,
+		sessions:   make(map[string]*recordingSession),
+	}, nil
+}
+
+func (r *recorder) Start(cam camera.CameraService) error {
+	details := cam.GetDetails()
+	id := details.ID
+
+	modeName := details.Settings.RecordingMode
+	if modeName == "" {
+		modeName = r.config.Recording.Mode
+	}
+	mode, err := recording.ParseMode(modeName)
+	if err != nil {
+		return err
+	}
+	if mode == recording.ModeOff {
+		r.logger.Info("Recording disabled for camera %s", id)
+		return nil
+	}
+
+	dir := filepath.Join(r.basePath, id)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating recordings directory %s: %v", dir, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sessions[id]; exists {
+		return fmt.Errorf("camera %s is already recording", id)
+	}
+
+	ctx, cancel := context.WithCancel(r.ctx)
+	session := &recordingSession{cancel: cancel}
+	r.sessions[id] = session
+
+	opts := recording.Options{
+		Dir:             dir,
+		MotionOnly:      mode == recording.ModeMotion,
+		PreRoll:         r.config.Recording.PreRoll,
+		PostRoll:        r.config.Recording.PostRoll,
+		SegmentDuration: r.config.Recording.SegmentDuration,
+		MaxSegmentSize:  r.config.Recording.MaxSegmentSizeMB * 1024 * 1024,
+		OnSegment:       r.onSegment,
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.remove(id, session)
+
+		r.logger.Info("Recording camera %s (%s) to %s", id, mode, dir)
+		if err := cam.RecordVideo(ctx, opts); err != nil {
+			r.logger.Error("Error recording camera %s: %v", id, err)
+		}
+		r.logger.Info("Recording stopped for camera %s", id)
+	}()
+
+	return nil
+}
+
+func (r *recorder) onSegment(segment recording.Segment) {
+	r.logger.Info("Recorded segment %s for camera %s (%s, %d bytes)", segment.Path, segment.CameraID, segment.End.Sub(segment.Start).Round(time.Second), segment.Size)
+
+	// Not bound to r.ctx, the last segment is finalized while shutting down
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if _, err := r.repository.Save(ctx, segment); err != nil {
+		r.logger.Error("Error indexing segment %s: %v", segment.Path, err)
+	}
+}
+
+func (r *recorder) remove(cameraID string, session *recordingSession) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, ok := r.sessions[cameraID]; ok && current == session {
+		delete(r.sessions, cameraID)
+	}
+	session.cancel()
+}
+
+func (r *recorder) Stop(cameraID string) {
+	r.mu.Lock()
+	session, ok := r.sessions[cameraID]
+	r.mu.Unlock()
+
+	if ok {
+		r.remove(cameraID, session)
+	}
+}
+
+func (r *recorder) Close() {
+	r.cancel()
+	r.wg.Wait()
+}
@@ -0,0 +1,73 @@
+package monitoring_use_cases
+
+import (
+	"context"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	"monitoring-system/src/pkg/app_error"
+	"monitoring-system/src/pkg/logger"
+	"os"
+)
+
+type RecordingsUseCase interface {
+	ListRecordings(ctx context.Context, filter recording.Filter) (recording.SegmentPage, error)
+	OpenRecording(ctx context.Context, id string) (*recording.Segment, *os.File, error)
+	SyncCatalog(ctx context.Context) error
+}
+
+type recordingsUseCase struct {
+	repository recording.Repository
+	storage    recording.Storage
+	logger     logger.Logger
+}
+
+func NewRecordingsUseCase(repository recording.Repository, storage recording.Storage, logger logger.Logger) RecordingsUseCase {
+	return &recordingsUseCase{
+		repository: repository,
+		storage:    storage,
+		logger:     logger,
+	}
+}
+
+func (uc *recordingsUseCase) ListRecordings(ctx context.Context, filter recording.Filter) (recording.SegmentPage, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return recording.SegmentPage{}, app_error.NewApiError(400, "Invalid time window", "'to' must be after 'from'")
+	}
+
+	return uc.repository.List(ctx, filter)
+}
+
+func (uc *recordingsUseCase) OpenRecording(ctx context.Context, id string) (*recording.Segment, *os.File, error) {
+	segment, err := uc.repository.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, err := uc.storage.Open(*segment)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, app_error.NewApiError(404, "Recording file not found")
+		}
+		uc.logger.Error("Error opening recording %s: %v", segment.Path, err)
+		return nil, nil, err
+	}
+
+	return segment, file, nil
+}
+
+// SyncCatalog indexes segments found on disk that are missing from the catalog,
+// e.g. recorded before the catalog existed or while the database was unavailable.
+func (uc *recordingsUseCase) SyncCatalog(ctx context.Context) error {
+	segments, err := uc.storage.List()
+	if err != nil {
+		return err
+	}
+
+	for _, segment := range segments {
+		if _, err := uc.repository.Save(ctx, segment); err != nil {
+			return err
+		}
+	}
+
+	uc.logger.Info("Recordings catalog synced with %d segments on disk", len(segments))
+	return nil
+}
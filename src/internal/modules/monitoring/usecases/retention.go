@@ -0,0 +1,195 @@
+package monitoring_use_cases
+
+import (
+	"context"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	"monitoring-system/src/pkg/logger"
+	"sort"
+	"sync"
+	"time"
+)
+
+const bytesPerMB = 1024 * 1024
+
+type StorageInfoUseCase interface {
+	GetStorageUsage() (recording.StorageUsage, error)
+}
+
+type RetentionManager interface {
+	StorageInfoUseCase
+	Start(ctx context.Context)
+	Enforce() error
+}
+
+type retentionManager struct {
+	logger     logger.Logger
+	config     *config.RetentionConfig
+	storage    recording.Storage
+	repository recording.Repository
+	mu         sync.Mutex
+}
+
+func NewRetentionManager(logger logger.Logger, config *config.RetentionConfig, storage recording.Storage, repository recording.Repository) RetentionManager {
+	return &retentionManager{
+		logger:     logger,
+		config:     config,
+		storage:    storage,
+		repository: repository,
+	}
+}
+
+func (rm *retentionManager) Start(ctx context.Context) {
+	interval := rm.config.CheckInterval
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := rm.Enforce(); err != nil {
+				rm.logger.Error("Error enforcing retention policy %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (rm *retentionManager) Enforce() error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	segments, err := rm.storage.List()
+	if err != nil {
+		return err
+	}
+
+	// Oldest first, so every rule below deletes from the front
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].Start.Before(segments[j].Start)
+	})
+
+	// The newest segment of each camera may still be open by the recorder
+	newest := make(map[string]int)
+	for i, segment := range segments {
+		newest[segment.CameraID] = i
+	}
+
+	deleted := make(map[int]bool)
+	remove := func(i int, reason string) {
+		segment := segments[i]
+		if err := rm.storage.Delete(segment); err != nil {
+			rm.logger.Error("Error deleting segment %s: %v", segment.Path, err)
+			return
+		}
+		if err := rm.repository.DeleteByPath(context.Background(), segment.Path); err != nil {
+			rm.logger.Error("Error removing segment %s from catalog: %v", segment.Path, err)
+		}
+		rm.logger.Info("Deleted segment %s (%s)", segment.Path, reason)
+		deleted[i] = true
+	}
+	deletable := func(i int) bool {
+		return !deleted[i] && newest[segments[i].CameraID] != i
+	}
+
+	if rm.config.MaxAge > 0 {
+		limit := time.Now().Add(-rm.config.MaxAge)
+		for i, segment := range segments {
+			if deletable(i) && segment.End.Before(limit) {
+				remove(i, "max age exceeded")
+			}
+		}
+	}
+
+	if rm.config.CameraQuotaMB > 0 {
+		quota := rm.config.CameraQuotaMB * bytesPerMB
+		used := make(map[string]int64)
+		for i, segment := range segments {
+			if !deleted[i] {
+				used[segment.CameraID] += segment.Size
+			}
+		}
+
+		for i, segment := range segments {
+			if deletable(i) && used[segment.CameraID] > quota {
+				remove(i, "camera quota exceeded")
+				if deleted[i] {
+					used[segment.CameraID] -= segment.Size
+				}
+			}
+		}
+	}
+
+	if rm.config.MinFreeMB > 0 {
+		minFree := uint64(rm.config.MinFreeMB) * bytesPerMB
+		for i := range segments {
+			_, free, err := rm.storage.DiskUsage()
+			if err != nil {
+				return err
+			}
+			if free >= minFree {
+				break
+			}
+			if deletable(i) {
+				remove(i, "free space below threshold")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (rm *retentionManager) GetStorageUsage() (recording.StorageUsage, error) {
+	segments, err := rm.storage.List()
+	if err != nil {
+		return recording.StorageUsage{}, err
+	}
+
+	total, free, err := rm.storage.DiskUsage()
+	if err != nil {
+		return recording.StorageUsage{}, err
+	}
+
+	usage := recording.StorageUsage{
+		Path:       rm.storage.Path(),
+		TotalBytes: total,
+		FreeBytes:  free,
+		Cameras:    []recording.CameraUsage{},
+	}
+
+	cameras := make(map[string]*recording.CameraUsage)
+	var ids []string
+	for _, segment := range segments {
+		cam, ok := cameras[segment.CameraID]
+		if !ok {
+			cam = &recording.CameraUsage{CameraID: segment.CameraID, Oldest: segment.Start, Newest: segment.End}
+			cameras[segment.CameraID] = cam
+			ids = append(ids, segment.CameraID)
+		}
+
+		cam.Bytes += segment.Size
+		cam.Segments++
+		if segment.Start.Before(cam.Oldest) {
+			cam.Oldest = segment.Start
+		}
+		if segment.End.After(cam.Newest) {
+			cam.Newest = segment.End
+		}
+		usage.UsedBytes += segment.Size
+	}
+
+	sort.Strings(ids)
+	for _, id := range ids {
+		usage.Cameras = append(usage.Cameras, *cameras[id])
+	}
+
+	return usage, nil
+}
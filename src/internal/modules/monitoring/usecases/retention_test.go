@@ -0,0 +1,158 @@
+package monitoring_use_cases
+
+import (
+	"context"
+	"monitoring-system/src/config"
+	"monitoring-system/src/internal/modules/monitoring/domain/recording"
+	recording_infra "monitoring-system/src/internal/modules/monitoring/infra/recording"
+	"monitoring-system/src/pkg/logger"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// retentionCatalog checks that files are gone before their catalog rows
+type retentionCatalog struct {
+	recording.Repository
+	t       *testing.T
+	deleted []string
+}
+
+func (c *retentionCatalog) DeleteByPath(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		c.t.Errorf("catalog row of %s removed before its file", path)
+	}
+	c.deleted = append(c.deleted, path)
+	return nil
+}
+
+type retentionSegment struct {
+	name     string
+	cameraID string
+	age      time.Duration
+	sizeMB   int64
+}
+
+func TestRetentionEnforce(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   config.RetentionConfig
+		segments []retentionSegment
+		deleted  []string
+	}{
+		{
+			name:   "age cutoff",
+			config: config.RetentionConfig{MaxAge: 24 * time.Hour},
+			segments: []retentionSegment{
+				{"a1", "a", 72 * time.Hour, 1},
+				{"a2", "a", 48 * time.Hour, 1},
+				{"a3", "a", time.Hour, 1},
+				{"b1", "b", 30 * time.Hour, 1},
+				{"b2", "b", 2 * time.Hour, 1},
+			},
+			deleted: []string{"a1", "a2", "b1"},
+		},
+		{
+			name:   "newest segment kept past the age cutoff",
+			config: config.RetentionConfig{MaxAge: 24 * time.Hour},
+			segments: []retentionSegment{
+				{"a1", "a", 72 * time.Hour, 1},
+				{"a2", "a", 48 * time.Hour, 1},
+			},
+			deleted: []string{"a1"},
+		},
+		{
+			name:   "quota evicts the oldest first",
+			config: config.RetentionConfig{CameraQuotaMB: 2},
+			segments: []retentionSegment{
+				{"a2", "a", 2 * time.Hour, 1},
+				{"a1", "a", 3 * time.Hour, 1},
+				{"a3", "a", time.Hour, 1},
+				{"b1", "b", 3 * time.Hour, 2},
+			},
+			deleted: []string{"a1"},
+		},
+		{
+			name:   "quota keeps the newest segment",
+			config: config.RetentionConfig{CameraQuotaMB: 1},
+			segments: []retentionSegment{
+				{"a1", "a", 2 * time.Hour, 1},
+				{"a2", "a", time.Hour, 3},
+			},
+			deleted: []string{"a1"},
+		},
+		{
+			name:   "age cutoff before quota",
+			config: config.RetentionConfig{MaxAge: 24 * time.Hour, CameraQuotaMB: 2},
+			segments: []retentionSegment{
+				{"a1", "a", 48 * time.Hour, 1},
+				{"a2", "a", 3 * time.Hour, 1},
+				{"a3", "a", 2 * time.Hour, 1},
+				{"a4", "a", time.Hour, 1},
+			},
+			deleted: []string{"a1", "a2"},
+		},
+	}
+
+	log, err := logger.NewLogger("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storage, err := recording_infra.NewFileStorage(t.TempDir(), log)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			now := time.Now()
+			paths := make(map[string]string)
+			for _, segment := range tt.segments {
+				dir := filepath.Join(storage.Path(), segment.cameraID)
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				start := now.Add(-segment.age)
+				path := filepath.Join(dir, recording.SegmentFileName(segment.cameraID, start, 0))
+				if err := os.WriteFile(path, nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Truncate(path, segment.sizeMB*bytesPerMB); err != nil {
+					t.Fatal(err)
+				}
+				end := start.Add(time.Minute)
+				if err := os.Chtimes(path, end, end); err != nil {
+					t.Fatal(err)
+				}
+				paths[path] = segment.name
+			}
+
+			catalog := &retentionCatalog{t: t}
+			rm := NewRetentionManager(log, &tt.config, storage, catalog)
+			if err := rm.Enforce(); err != nil {
+				t.Fatal(err)
+			}
+
+			var deleted []string
+			for _, path := range catalog.deleted {
+				deleted = append(deleted, paths[path])
+			}
+			sort.Strings(deleted)
+			if strings.Join(deleted, ",") != strings.Join(tt.deleted, ",") {
+				t.Fatalf("deleted %v, want %v", deleted, tt.deleted)
+			}
+
+			for path, name := range paths {
+				_, err := os.Stat(path)
+				if removed := os.IsNotExist(err); removed != slices.Contains(tt.deleted, name) {
+					t.Errorf("segment %s removed from disk = %v", name, removed)
+				}
+			}
+		})
+	}
+}
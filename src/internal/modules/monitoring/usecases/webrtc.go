@@ -0,0 +1,201 @@
+package monitoring_use_cases
+
+import (
+	"context"
+	"monitoring-system/src/config"
+	webrtc_infra "monitoring-system/src/internal/modules/monitoring/infra/webrtc"
+	"monitoring-system/src/pkg/app_error"
+	"monitoring-system/src/pkg/logger"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const NEGOTIATION_TIMEOUT = 10 * time.Second
+
+type WebRTCSession struct {
+	ID     string
+	Answer string
+}
+
+type WebRTCUseCase interface {
+	// Offer answers the SDP offer of a viewer, starting the camera encoder if
+	// nobody was watching it.
+	Offer(ctx context.Context, cameraID, offer string) (WebRTCSession, error)
+	Hangup(cameraID, sessionID string) error
+}
+
+type WebRTCManager interface {
+	WebRTCUseCase
+	Close()
+}
+
+type webrtcPublisher struct {
+	publisher *webrtc_infra.Publisher
+	peers     map[string]*webrtc_infra.Peer
+}
+
+type webrtcManager struct {
+	logger        logger.Logger
+	ctx           context.Context
+	cancel        context.CancelFunc
+	config        *config.WebRTCConfig
+	cameraManager CameraManager
+	publishers    map[string]*webrtcPublisher
+	mu            sync.Mutex
+}
+
+func NewWebRTCManager(ctx context.Context, logger logger.Logger, config *config.WebRTCConfig, cameraManager CameraManager) WebRTCManager {
+	ctx, cancel := context.WithCancel(ctx)
+	return &webrtcManager{
+		logger:        logger,
+		ctx:           ctx,
+		cancel:        cancel,
+		config:        config,
+		cameraManager: cameraManager,
+		publishers:    make(map[string]*webrtcPublisher),
+	}
+}
+
+// publisher must be called with the lock held, it reuses the running encoder
+// of the camera unless it stopped or the camera was restarted.
+func (wm *webrtcManager) publisher(cameraID string) (*webrtcPublisher, error) {
+	cam, ok := wm.cameraManager.GetCameras()[cameraID]
+	if !ok {
+		return nil, app_error.NewApiError(404, "Camera not found", cameraID)
+	}
+
+	if p, ok := wm.publishers[cameraID]; ok {
+		select {
+		case <-p.publisher.Done():
+		default:
+			if p.publisher.Camera() == cam {
+				return p, nil
+			}
+		}
+		wm.stop(cameraID, p)
+	}
+
+	publisher, err := webrtc_infra.NewPublisher(cam, wm.config, wm.logger)
+	if err == nil {
+		err = publisher.Start(wm.ctx)
+	}
+	if err != nil {
+		wm.logger.Error("Error starting WebRTC stream for camera %s: %v", cameraID, err)
+		return nil, app_error.NewApiError(503, "WebRTC stream unavailable", err.Error())
+	}
+	wm.logger.Info("WebRTC stream started for camera %s", cameraID)
+
+	p := &webrtcPublisher{publisher: publisher, peers: make(map[string]*webrtc_infra.Peer)}
+	wm.publishers[cameraID] = p
+	go func() {
+		<-publisher.Done()
+		wm.mu.Lock()
+		defer wm.mu.Unlock()
+		if wm.publishers[cameraID] == p {
+			wm.stop(cameraID, p)
+		}
+	}()
+	return p, nil
+}
+
+// stop must be called with the lock held, it hangs up every viewer of the
+// camera.
+func (wm *webrtcManager) stop(cameraID string, p *webrtcPublisher) {
+	if wm.publishers[cameraID] == p {
+		delete(wm.publishers, cameraID)
+	}
+	for _, peer := range p.peers {
+		if peer != nil {
+			peer.Close()
+		}
+	}
+	go p.publisher.Close()
+}
+
+func (wm *webrtcManager) Offer(ctx context.Context, cameraID, offer string) (WebRTCSession, error) {
+	if !wm.config.Enabled {
+		return WebRTCSession{}, app_error.NewApiError(404, "WebRTC is disabled")
+	}
+
+	session := WebRTCSession{ID: uuid.New().String()}
+
+	// The session is reserved while negotiating so the encoder keeps running
+	wm.mu.Lock()
+	p, err := wm.publisher(cameraID)
+	if err == nil {
+		p.peers[session.ID] = nil
+	}
+	wm.mu.Unlock()
+	if err != nil {
+		return WebRTCSession{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, NEGOTIATION_TIMEOUT)
+	defer cancel()
+
+	peer, answer, err := webrtc_infra.NewPeer(ctx, wm.config, p.publisher.Track(), offer)
+	if err != nil {
+		wm.release(cameraID, p, session.ID)
+		return WebRTCSession{}, app_error.NewApiError(400, "WebRTC negotiation failed", err.Error())
+	}
+	session.Answer = answer
+
+	wm.mu.Lock()
+	stopped := wm.publishers[cameraID] != p
+	if !stopped {
+		p.peers[session.ID] = peer
+	}
+	wm.mu.Unlock()
+	if stopped {
+		peer.Close()
+		return WebRTCSession{}, app_error.NewApiError(503, "WebRTC stream stopped", cameraID)
+	}
+
+	go func() {
+		<-peer.Done()
+		wm.release(cameraID, p, session.ID)
+	}()
+
+	wm.logger.Info("WebRTC session %s started for camera %s", session.ID, cameraID)
+	return session, nil
+}
+
+// release forgets a viewer and stops the encoder once nobody is watching.
+func (wm *webrtcManager) release(cameraID string, p *webrtcPublisher, sessionID string) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	delete(p.peers, sessionID)
+	if len(p.peers) == 0 && wm.publishers[cameraID] == p {
+		wm.logger.Info("Stopping WebRTC stream for camera %s", cameraID)
+		wm.stop(cameraID, p)
+	}
+}
+
+func (wm *webrtcManager) Hangup(cameraID, sessionID string) error {
+	wm.mu.Lock()
+	var peer *webrtc_infra.Peer
+	if p, ok := wm.publishers[cameraID]; ok {
+		peer = p.peers[sessionID]
+	}
+	wm.mu.Unlock()
+
+	if peer == nil {
+		return app_error.NewApiError(404, "WebRTC session not found", sessionID)
+	}
+	peer.Close()
+	return nil
+}
+
+func (wm *webrtcManager) Close() {
+	wm.cancel()
+
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+
+	for id, p := range wm.publishers {
+		wm.stop(id, p)
+	}
+}
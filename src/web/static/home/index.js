@@ -11,6 +11,7 @@ document.addEventListener("DOMContentLoaded", async function () {
     return;
   }
 
+  const RECONNECT_DELAY_MS = 5000;
   let previousUrls = [];
 
   const fetchCameraDetails = async () => {
@@ -41,6 +42,16 @@ document.addEventListener("DOMContentLoaded", async function () {
       const div = document.createElement("div");
       div.classList.add("mb-3");
       div.innerHTML = `
+      <video
+        id="webrtcStream${camera.ID}"
+        width="640"
+        height="480"
+        class="videoStream mb-3"
+        style="display: none"
+        autoplay
+        muted
+        playsinline
+      ></video>
       <img
         id="videoStream${camera.ID}"
         width="640"
@@ -49,6 +60,9 @@ document.addEventListener("DOMContentLoaded", async function () {
         class="img-fluid videoStream mb-3"
         onerror="this.style.backgroundColor='black'; this.src='';"
       />
+      <p class="text-muted">
+        ${camera.Name} - <span id="cameraState${camera.ID}">${camera.Status.State}</span>
+      </p>
     `;
       container.appendChild(div);
       previousUrls.push(null);
@@ -64,6 +78,12 @@ document.addEventListener("DOMContentLoaded", async function () {
     ws.binaryType = "arraybuffer";
 
     ws.onmessage = function (event) {
+      // Text messages report the stream settings, frames are binary
+      if (typeof event.data === "string") {
+        console.debug(`Stream of camera ${cameraIndex}:`, JSON.parse(event.data));
+        return;
+      }
+
       const arrayBuffer = event.data;
       const blob = new Blob([arrayBuffer], { type: "image/jpeg" });
       const imageUrl = URL.createObjectURL(blob);
@@ -75,8 +95,14 @@ document.addEventListener("DOMContentLoaded", async function () {
       const videoStream = document.getElementById(`videoStream${cameraIndex}`);
       if (videoStream) {
         videoStream.src = imageUrl;
+        videoStream.style.display = "";
         previousUrls[cameraIndex] = imageUrl;
       }
+
+      const webrtcStream = document.getElementById(`webrtcStream${cameraIndex}`);
+      if (webrtcStream) {
+        webrtcStream.style.display = "none";
+      }
     };
 
     ws.onclose = function (event) {
@@ -92,8 +118,14 @@ document.addEventListener("DOMContentLoaded", async function () {
           setTimeout(() => {
             window.location.href = "/web/login";
           }, 3000);
+          return;
         }
       }
+
+      // The server restores disconnected cameras under the same ID
+      setTimeout(() => {
+        connectWebSocket(cameraIndex);
+      }, RECONNECT_DELAY_MS);
     };
 
     ws.onerror = function (event) {
@@ -108,11 +140,85 @@ document.addEventListener("DOMContentLoaded", async function () {
     };
   };
 
+  const waitIceGathering = (pc) =>
+    new Promise((resolve) => {
+      if (pc.iceGatheringState === "complete") {
+        resolve();
+        return;
+      }
+      pc.addEventListener("icegatheringstatechange", () => {
+        if (pc.iceGatheringState === "complete") {
+          resolve();
+        }
+      });
+    });
+
+  // WebRTC through WHEP, the JPEG websocket stream is kept as a fallback for
+  // browsers or servers where it isn't available
+  const connectWebRTC = async (cameraID) => {
+    const pc = new RTCPeerConnection();
+    pc.addTransceiver("video", { direction: "recvonly" });
+
+    const video = document.getElementById(`webrtcStream${cameraID}`);
+    const img = document.getElementById(`videoStream${cameraID}`);
+    pc.ontrack = (event) => {
+      video.srcObject = event.streams[0] || new MediaStream([event.track]);
+      video.style.display = "";
+      img.style.display = "none";
+    };
+
+    await pc.setLocalDescription(await pc.createOffer());
+    await waitIceGathering(pc);
+
+    const response = await fetch(
+      `/api/v1/monitoring/cameras/${cameraID}/whep`,
+      {
+        method: "POST",
+        headers: {
+          Authorization: `Bearer ${token}`,
+          "Content-Type": "application/sdp",
+        },
+        body: pc.localDescription.sdp,
+      }
+    );
+    if (!response.ok) {
+      pc.close();
+      throw new Error(`WHEP request failed with status ${response.status}`);
+    }
+
+    const session = response.headers.get("Location");
+    await pc.setRemoteDescription({
+      type: "answer",
+      sdp: await response.text(),
+    });
+
+    pc.onconnectionstatechange = () => {
+      if (pc.connectionState !== "failed" && pc.connectionState !== "closed") {
+        return;
+      }
+      pc.close();
+      if (session) {
+        fetch(session, {
+          method: "DELETE",
+          headers: { Authorization: `Bearer ${token}` },
+        }).catch(() => {});
+      }
+
+      // The server restores disconnected cameras under the same ID
+      setTimeout(() => {
+        connectWebRTC(cameraID).catch(() => connectWebSocket(cameraID));
+      }, RECONNECT_DELAY_MS);
+    };
+  };
+
   const cameraDetails = await fetchCameraDetails();
   if (cameraDetails.length > 0) {
     createVideoElements(cameraDetails);
     cameraDetails.forEach((camera) => {
-      connectWebSocket(camera.ID);
+      connectWebRTC(camera.ID).catch((error) => {
+        console.warn(`WebRTC unavailable for camera ${camera.ID}:`, error);
+        connectWebSocket(camera.ID);
+      });
     });
   } else {
     messageDiv.textContent = "No cameras found.";